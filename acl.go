@@ -0,0 +1,457 @@
+package fs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Extended attributes holding the POSIX ACLs of a file.
+const (
+	xattrACLAccess  = "system.posix_acl_access"
+	xattrACLDefault = "system.posix_acl_default"
+)
+
+const (
+	aclVersion     = 2
+	aclUndefinedID = ^uint32(0)
+	aclHeaderSize  = 4
+	aclEntrySize   = 8
+)
+
+// ACLType selects the access or the default ACL of a file.
+type ACLType int
+
+const (
+	// ACLAccess is the ACL that controls access to the file.
+	ACLAccess ACLType = iota
+	// ACLDefault is the ACL inherited by files created in a directory.
+	ACLDefault
+)
+
+func (t ACLType) xattr() string {
+	if t == ACLDefault {
+		return xattrACLDefault
+	}
+	return xattrACLAccess
+}
+
+// ACLTag is the type of an ACL entry.
+type ACLTag uint16
+
+// These are the ACL entry types, the values match the on-disk encoding.
+const (
+	ACLUserObj  ACLTag = 0x01 // owner of the file
+	ACLUser     ACLTag = 0x02 // user identified by ID
+	ACLGroupObj ACLTag = 0x04 // owning group of the file
+	ACLGroup    ACLTag = 0x08 // group identified by ID
+	ACLMask     ACLTag = 0x10 // upper bound of user, group and group object entries
+	ACLOther    ACLTag = 0x20 // everyone else
+)
+
+var aclTagNames = map[ACLTag]string{
+	ACLUserObj:  "user",
+	ACLUser:     "user",
+	ACLGroupObj: "group",
+	ACLGroup:    "group",
+	ACLMask:     "mask",
+	ACLOther:    "other",
+}
+
+// ACLPerm is the set of permissions granted by an ACL entry.
+type ACLPerm uint16
+
+// These are the ACL permissions.
+const (
+	ACLExecute ACLPerm = 0x1
+	ACLWrite   ACLPerm = 0x2
+	ACLRead    ACLPerm = 0x4
+)
+
+// String returns the permissions in the form used by getfacl, e.g. "r-x".
+func (p ACLPerm) String() string {
+	b := []byte("---")
+	if p&ACLRead != 0 {
+		b[0] = 'r'
+	}
+	if p&ACLWrite != 0 {
+		b[1] = 'w'
+	}
+	if p&ACLExecute != 0 {
+		b[2] = 'x'
+	}
+	return string(b)
+}
+
+// An ACLEntry grants permissions to a user or group.
+type ACLEntry struct {
+	Tag  ACLTag
+	ID   uint32 // user or group ID, only used by ACLUser and ACLGroup entries
+	Perm ACLPerm
+}
+
+func (e ACLEntry) hasID() bool {
+	return e.Tag == ACLUser || e.Tag == ACLGroup
+}
+
+// String returns the entry in the form used by getfacl -n, e.g.
+// "user:1000:rw-".
+func (e ACLEntry) String() string {
+	var id string
+	if e.hasID() {
+		id = strconv.FormatUint(uint64(e.ID), 10)
+	}
+	name, ok := aclTagNames[e.Tag]
+	if !ok {
+		name = "0x" + strconv.FormatUint(uint64(e.Tag), 16)
+	}
+	return name + ":" + id + ":" + e.Perm.String()
+}
+
+// An ACL is a POSIX access control list.
+type ACL []ACLEntry
+
+// ACLFromMode returns the minimal ACL equivalent to the permission bits of
+// mode.
+func ACLFromMode(mode os.FileMode) ACL {
+	return ACL{
+		{Tag: ACLUserObj, Perm: ACLPerm(mode>>6) & 7},
+		{Tag: ACLGroupObj, Perm: ACLPerm(mode>>3) & 7},
+		{Tag: ACLOther, Perm: ACLPerm(mode) & 7},
+	}
+}
+
+// Mode returns the permission bits corresponding to the ACL, as reported
+// by Stat. If the ACL has a mask entry it determines the group permission
+// bits.
+func (a ACL) Mode() os.FileMode {
+	var owner, group, mask, other ACLPerm
+	hasMask := false
+	for _, e := range a {
+		switch e.Tag {
+		case ACLUserObj:
+			owner = e.Perm
+		case ACLGroupObj:
+			group = e.Perm
+		case ACLMask:
+			mask, hasMask = e.Perm, true
+		case ACLOther:
+			other = e.Perm
+		}
+	}
+	if hasMask {
+		group = mask
+	}
+	return os.FileMode(owner&7)<<6 | os.FileMode(group&7)<<3 | os.FileMode(other&7)
+}
+
+// sorted returns a copy of the ACL in the canonical order required by the
+// kernel: by tag and then by ID.
+func (a ACL) sorted() ACL {
+	s := make(ACL, len(a))
+	copy(s, a)
+	sort.SliceStable(s, func(i, j int) bool {
+		if s[i].Tag != s[j].Tag {
+			return s[i].Tag < s[j].Tag
+		}
+		return s[i].ID < s[j].ID
+	})
+	return s
+}
+
+// Valid returns an error if the ACL is not well formed: it must have
+// exactly one user object, group object and other entry, at most one entry
+// per user and group ID and a mask entry if it has user or group entries.
+func (a ACL) Valid() error {
+	counts := make(map[ACLTag]int)
+	ids := make(map[ACLEntry]bool)
+	for _, e := range a {
+		if _, ok := aclTagNames[e.Tag]; !ok {
+			return fmt.Errorf("fs: invalid ACL entry tag 0x%x", uint16(e.Tag))
+		}
+		if e.Perm&^(ACLRead|ACLWrite|ACLExecute) != 0 {
+			return fmt.Errorf("fs: invalid ACL entry permissions 0x%x", uint16(e.Perm))
+		}
+		counts[e.Tag]++
+		if e.hasID() {
+			key := ACLEntry{Tag: e.Tag, ID: e.ID}
+			if ids[key] {
+				return fmt.Errorf("fs: duplicate ACL entry %s", e)
+			}
+			ids[key] = true
+		}
+	}
+	for _, tag := range []ACLTag{ACLUserObj, ACLGroupObj, ACLOther} {
+		if counts[tag] != 1 {
+			return fmt.Errorf("fs: ACL must have exactly one %s entry", ACLEntry{Tag: tag})
+		}
+	}
+	if counts[ACLMask] > 1 {
+		return errors.New("fs: ACL has more than one mask entry")
+	}
+	if counts[ACLMask] == 0 && counts[ACLUser]+counts[ACLGroup] > 0 {
+		return errors.New("fs: ACL with user or group entries must have a mask entry")
+	}
+	return nil
+}
+
+// MarshalBinary encodes the ACL in the format of the
+// system.posix_acl_access and system.posix_acl_default extended attributes.
+// The entries are sorted in canonical order.
+func (a ACL) MarshalBinary() ([]byte, error) {
+	b := make([]byte, aclHeaderSize, aclHeaderSize+len(a)*aclEntrySize)
+	binary.LittleEndian.PutUint32(b, aclVersion)
+	for _, e := range a.sorted() {
+		id := aclUndefinedID
+		if e.hasID() {
+			id = e.ID
+		}
+		var buf [aclEntrySize]byte
+		binary.LittleEndian.PutUint16(buf[0:], uint16(e.Tag))
+		binary.LittleEndian.PutUint16(buf[2:], uint16(e.Perm))
+		binary.LittleEndian.PutUint32(buf[4:], id)
+		b = append(b, buf[:]...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes an ACL encoded by MarshalBinary.
+func (a *ACL) UnmarshalBinary(b []byte) error {
+	if len(b) < aclHeaderSize || (len(b)-aclHeaderSize)%aclEntrySize != 0 {
+		return fmt.Errorf("fs: invalid ACL size %d", len(b))
+	}
+	if v := binary.LittleEndian.Uint32(b); v != aclVersion {
+		return fmt.Errorf("fs: unsupported ACL version %d", v)
+	}
+	acl := make(ACL, 0, (len(b)-aclHeaderSize)/aclEntrySize)
+	for b = b[aclHeaderSize:]; len(b) > 0; b = b[aclEntrySize:] {
+		e := ACLEntry{
+			Tag:  ACLTag(binary.LittleEndian.Uint16(b[0:])),
+			Perm: ACLPerm(binary.LittleEndian.Uint16(b[2:])),
+		}
+		if e.hasID() {
+			e.ID = binary.LittleEndian.Uint32(b[4:])
+		}
+		acl = append(acl, e)
+	}
+	*a = acl
+	return nil
+}
+
+// String returns the ACL in the text form used by getfacl -n, one entry
+// per line.
+func (a ACL) String() string {
+	return FormatACL(a, nil)
+}
+
+// FormatACL returns the access and default ACLs in the text form used by
+// getfacl -n. The entries of the default ACL are prefixed with "default:".
+func FormatACL(access, def ACL) string {
+	var b strings.Builder
+	for _, e := range access {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	for _, e := range def {
+		b.WriteString("default:")
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ParseACL parses ACLs in the text form accepted by setfacl and returned by
+// getfacl. Entries are separated by newlines or commas, text following a
+// '#' is ignored. Tags may be abbreviated to their first letter, users and
+// groups may be given by name or ID and permissions either as a
+// combination of "rwx" and '-' or as an octal digit. Entries prefixed with
+// "default:" or "d:" are returned in def.
+func ParseACL(s string) (access, def ACL, err error) {
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, field := range strings.Split(line, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			isDefault := false
+			if rest, ok := trimACLPrefix(field, "default:", "d:"); ok {
+				isDefault = true
+				field = rest
+			}
+			e, err := parseACLEntry(field)
+			if err != nil {
+				return nil, nil, err
+			}
+			if isDefault {
+				def = append(def, e)
+			} else {
+				access = append(access, e)
+			}
+		}
+	}
+	return access, def, nil
+}
+
+func trimACLPrefix(s string, prefixes ...string) (string, bool) {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return s[len(p):], true
+		}
+	}
+	return s, false
+}
+
+func parseACLEntry(s string) (ACLEntry, error) {
+	parts := strings.Split(s, ":")
+	// The qualifier of mask and other entries may be omitted.
+	if len(parts) == 2 {
+		parts = []string{parts[0], "", parts[1]}
+	}
+	if len(parts) != 3 {
+		return ACLEntry{}, fmt.Errorf("fs: invalid ACL entry %q", s)
+	}
+	tag, qualifier, perm := parts[0], strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+
+	var e ACLEntry
+	switch tag {
+	case "user", "u":
+		e.Tag = ACLUserObj
+		if qualifier != "" {
+			e.Tag = ACLUser
+			id, err := lookupACLID(qualifier, false)
+			if err != nil {
+				return ACLEntry{}, err
+			}
+			e.ID = id
+		}
+	case "group", "g":
+		e.Tag = ACLGroupObj
+		if qualifier != "" {
+			e.Tag = ACLGroup
+			id, err := lookupACLID(qualifier, true)
+			if err != nil {
+				return ACLEntry{}, err
+			}
+			e.ID = id
+		}
+	case "mask", "m":
+		e.Tag = ACLMask
+	case "other", "o":
+		e.Tag = ACLOther
+	default:
+		return ACLEntry{}, fmt.Errorf("fs: invalid ACL entry tag %q", tag)
+	}
+	if qualifier != "" && !e.hasID() {
+		return ACLEntry{}, fmt.Errorf("fs: invalid ACL entry %q", s)
+	}
+
+	p, err := parseACLPerm(perm)
+	if err != nil {
+		return ACLEntry{}, err
+	}
+	e.Perm = p
+	return e, nil
+}
+
+func parseACLPerm(s string) (ACLPerm, error) {
+	if len(s) == 1 && '0' <= s[0] && s[0] <= '7' {
+		return ACLPerm(s[0] - '0'), nil
+	}
+	var p ACLPerm
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 'r':
+			p |= ACLRead
+		case 'w':
+			p |= ACLWrite
+		case 'x':
+			p |= ACLExecute
+		case '-':
+		default:
+			return 0, fmt.Errorf("fs: invalid ACL permissions %q", s)
+		}
+	}
+	return p, nil
+}
+
+// lookupACLID returns the ID of the named user or group, names that are
+// decimal numbers are used as IDs.
+func lookupACLID(name string, group bool) (uint32, error) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+	var id string
+	if group {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return 0, err
+		}
+		id = g.Gid
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return 0, err
+		}
+		id = u.Uid
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("fs: invalid ID %q of %s", id, name)
+	}
+	return uint32(n), nil
+}
+
+// GetACL returns the ACL of type typ of the named file. If the file has no
+// access ACL, the ACL equivalent to its permission bits is returned; if a
+// directory has no default ACL, an empty ACL is returned.
+// If there is an error, it will be of type *PathError.
+func GetACL(name string, typ ACLType) (ACL, error) {
+	data, err := Getxattr(name, typ.xattr())
+	if err != nil {
+		if !isNoXattr(err) {
+			return nil, err
+		}
+		if typ == ACLDefault {
+			return ACL{}, nil
+		}
+		fi, err := Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		return ACLFromMode(fi.Mode()), nil
+	}
+	var acl ACL
+	if err := acl.UnmarshalBinary(data); err != nil {
+		return nil, &os.PathError{Op: "getacl", Path: name, Err: err}
+	}
+	return acl, nil
+}
+
+// SetACL sets the ACL of type typ of the named file, which also updates
+// the permission bits of the file. Setting an empty default ACL removes
+// it.
+// If there is an error, it will be of type *PathError.
+func SetACL(name string, typ ACLType, acl ACL) error {
+	if typ == ACLDefault && len(acl) == 0 {
+		if err := Removexattr(name, xattrACLDefault); err != nil && !isNoXattr(err) {
+			return err
+		}
+		return nil
+	}
+	if err := acl.Valid(); err != nil {
+		return &os.PathError{Op: "setacl", Path: name, Err: err}
+	}
+	data, err := acl.MarshalBinary()
+	if err != nil {
+		return &os.PathError{Op: "setacl", Path: name, Err: err}
+	}
+	return Setxattr(name, typ.xattr(), data, 0)
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGetSetACL(t *testing.T) {
+	dir := newDir("TestGetSetACL", t)
+	defer RemoveAll(dir)
+	if err := Chmod(dir, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	acl, err := GetACL(dir, ACLAccess)
+	skipIfNoXattr(t, err)
+	if err != nil {
+		t.Fatalf("GetACL: %v", err)
+	}
+	if want := ACLFromMode(0750); !reflect.DeepEqual(acl, want) {
+		t.Errorf("GetACL without ACL = %v; want %v", acl, want)
+	}
+	if acl, err := GetACL(dir, ACLDefault); err != nil || len(acl) != 0 {
+		t.Errorf("GetACL default = %v, %v; want empty ACL", acl, err)
+	}
+
+	err = SetACL(dir, ACLAccess, testACL)
+	skipIfNoXattr(t, err)
+	if err != nil {
+		t.Fatalf("SetACL: %v", err)
+	}
+	if acl, err := GetACL(dir, ACLAccess); err != nil || !reflect.DeepEqual(acl, testACL) {
+		t.Errorf("GetACL = %v, %v; want %v", acl, err, testACL)
+	}
+	checkMode(t, dir, testACL.Mode())
+
+	def := ACLFromMode(0750)
+	if err := SetACL(dir, ACLDefault, def); err != nil {
+		t.Fatalf("SetACL default: %v", err)
+	}
+	if acl, err := GetACL(dir, ACLDefault); err != nil || !reflect.DeepEqual(acl, def) {
+		t.Errorf("GetACL default = %v, %v; want %v", acl, err, def)
+	}
+	// Files created in the directory inherit the default ACL.
+	f, err := OpenFile(dir+"/file", os.O_CREATE|os.O_WRONLY, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	checkMode(t, dir+"/file", 0750)
+
+	if err := SetACL(dir, ACLDefault, nil); err != nil {
+		t.Fatalf("SetACL empty default: %v", err)
+	}
+	if acl, err := GetACL(dir, ACLDefault); err != nil || len(acl) != 0 {
+		t.Errorf("GetACL default after removal = %v, %v; want empty ACL", acl, err)
+	}
+
+	if err := SetACL(dir, ACLAccess, ACL{testACL[0]}); err == nil {
+		t.Error("SetACL with invalid ACL: expected error")
+	}
+}
@@ -0,0 +1,161 @@
+package fs
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+var testACL = ACL{
+	{Tag: ACLUserObj, Perm: ACLRead | ACLWrite | ACLExecute},
+	{Tag: ACLUser, ID: 1000, Perm: ACLRead | ACLWrite},
+	{Tag: ACLGroupObj, Perm: ACLRead | ACLExecute},
+	{Tag: ACLGroup, ID: 50, Perm: ACLRead},
+	{Tag: ACLMask, Perm: ACLRead | ACLWrite | ACLExecute},
+	{Tag: ACLOther, Perm: 0},
+}
+
+// testACLBinary is testACL as encoded by the kernel, entries sorted by tag.
+var testACLBinary = []byte{
+	0x02, 0x00, 0x00, 0x00, // version
+	0x01, 0x00, 0x07, 0x00, 0xff, 0xff, 0xff, 0xff, // user::rwx
+	0x02, 0x00, 0x06, 0x00, 0xe8, 0x03, 0x00, 0x00, // user:1000:rw-
+	0x04, 0x00, 0x05, 0x00, 0xff, 0xff, 0xff, 0xff, // group::r-x
+	0x08, 0x00, 0x04, 0x00, 0x32, 0x00, 0x00, 0x00, // group:50:r--
+	0x10, 0x00, 0x07, 0x00, 0xff, 0xff, 0xff, 0xff, // mask::rwx
+	0x20, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff, // other::---
+}
+
+const testACLText = `user::rwx
+user:1000:rw-
+group::r-x
+group:50:r--
+mask::rwx
+other::---
+`
+
+func TestACLBinary(t *testing.T) {
+	// The encoding sorts the entries.
+	shuffled := ACL{testACL[5], testACL[3], testACL[0], testACL[4], testACL[1], testACL[2]}
+	b, err := shuffled.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, testACLBinary) {
+		t.Fatalf("MarshalBinary = %x; want %x", b, testACLBinary)
+	}
+	var acl ACL
+	if err := acl.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(acl, testACL) {
+		t.Fatalf("UnmarshalBinary = %v; want %v", acl, testACL)
+	}
+
+	for _, b := range [][]byte{
+		nil,
+		testACLBinary[:len(testACLBinary)-1],
+		{0x01, 0x00, 0x00, 0x00},
+	} {
+		if err := acl.UnmarshalBinary(b); err == nil {
+			t.Errorf("UnmarshalBinary(%x): expected error", b)
+		}
+	}
+}
+
+func TestACLText(t *testing.T) {
+	if s := testACL.String(); s != testACLText {
+		t.Errorf("String = %q; want %q", s, testACLText)
+	}
+	access, def, err := ParseACL(testACLText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(access, testACL) || def != nil {
+		t.Errorf("ParseACL = %v, %v; want %v, nil", access, def, testACL)
+	}
+
+	// Output of getfacl with comments and a default ACL.
+	const getfacl = `# file: dir
+# owner: root
+# group: root
+user::rwx
+user:1000:rw-			#effective:rw-
+group::r-x
+group:50:r--
+mask::rwx
+other::---
+default:user::rwx
+default:group::r-x
+default:other::r-x
+`
+	access, def, err = ParseACL(getfacl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDef := ACLFromMode(0755)
+	if !reflect.DeepEqual(access, testACL) || !reflect.DeepEqual(def, wantDef) {
+		t.Errorf("ParseACL = %v, %v; want %v, %v", access, def, testACL, wantDef)
+	}
+	if s := FormatACL(access, def); s != testACLText+"default:user::rwx\ndefault:group::r-x\ndefault:other::r-x\n" {
+		t.Errorf("FormatACL = %q", s)
+	}
+
+	// Short form accepted by setfacl.
+	access, def, err = ParseACL("u::7,u:1000:rw,g::rx,g:50:r,m::rwx,o::-,d:o:r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(access, testACL) || !reflect.DeepEqual(def, ACL{{Tag: ACLOther, Perm: ACLRead}}) {
+		t.Errorf("ParseACL = %v, %v", access, def)
+	}
+
+	for _, s := range []string{
+		"user",
+		"user::rwz",
+		"owner::rwx",
+		"mask:1000:rwx",
+		"user:1:2:rwx",
+		"user:no-such-user-fs-test:rwx",
+	} {
+		if _, _, err := ParseACL(s); err == nil {
+			t.Errorf("ParseACL(%q): expected error", s)
+		}
+	}
+}
+
+func TestACLValid(t *testing.T) {
+	if err := testACL.Valid(); err != nil {
+		t.Errorf("Valid(%v): %v", testACL, err)
+	}
+	if err := ACLFromMode(0644).Valid(); err != nil {
+		t.Errorf("Valid(ACLFromMode(0644)): %v", err)
+	}
+	invalid := []ACL{
+		nil,
+		{testACL[0], testACL[2]},
+		{testACL[0], testACL[1], testACL[2], testACL[5]},
+		append(ACL{testACL[1]}, testACL...),
+		append(ACL{testACL[4]}, testACL...),
+		append(ACL{{Tag: 0x40}}, testACL...),
+		{testACL[0], testACL[2], {Tag: ACLOther, Perm: 0x8}},
+	}
+	for _, acl := range invalid {
+		if err := acl.Valid(); err == nil {
+			t.Errorf("Valid(%v): expected error", acl)
+		}
+	}
+}
+
+func TestACLMode(t *testing.T) {
+	for _, mode := range []os.FileMode{0, 0644, 0755, 0700, 0007} {
+		if m := ACLFromMode(mode).Mode(); m != mode {
+			t.Errorf("ACLFromMode(%o).Mode() = %o", mode, m)
+		}
+	}
+	// The mask determines the group permissions.
+	if m := testACL.Mode(); m != 0770 {
+		t.Errorf("Mode = %o; want %o", m, 0770)
+	}
+}
@@ -0,0 +1,13 @@
+package fs
+
+// Flags of the *at system calls.
+const (
+	_AT_FDCWD            = -0x64
+	_AT_SYMLINK_NOFOLLOW = 0x100
+	_AT_SYMLINK_FOLLOW   = 0x400
+	_AT_EMPTY_PATH       = 0x1000
+)
+
+// _O_PATH opens a file only to refer to it, it is missing from package
+// syscall on some architectures.
+const _O_PATH = 0x200000
@@ -0,0 +1,19 @@
+//go:build dragonfly || openbsd || solaris
+// +build dragonfly openbsd solaris
+
+package fs
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileAtime returns the access time of fi, if available.
+func fileAtime(fi os.FileInfo) (time.Time, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Atim.Unix()), true
+}
@@ -0,0 +1,14 @@
+//go:build !darwin && !freebsd && !netbsd && !dragonfly && !openbsd && !solaris && !linux && !windows
+// +build !darwin,!freebsd,!netbsd,!dragonfly,!openbsd,!solaris,!linux,!windows
+
+package fs
+
+import (
+	"os"
+	"time"
+)
+
+// fileAtime returns the access time of fi, if available.
+func fileAtime(fi os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
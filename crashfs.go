@@ -0,0 +1,1102 @@
+package fs
+
+import (
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// A CrashFS is an in-memory FS that simulates which changes survive a
+// crash, such as a power loss, to test that code like atomic writes or
+// journals is crash-safe.
+//
+// Changes are volatile until they are synced: the data and attributes of a
+// file by syncing the file, the entries of a directory, that is the names
+// of files created, linked, renamed or removed in it, by syncing the
+// directory. After a crash the file system contains the synced changes
+// and possibly some of the volatile ones:
+//
+//   - Changes of directory entries and attributes persist in the order
+//     they were made, as with a journal: syncing one of them also syncs
+//     those made before it. Renames are atomic and are synced by syncing
+//     either directory.
+//   - Writes and truncations of each file persist in the order they were
+//     made, independently of other files and of directory entries.
+//
+// A file whose directory entry did not persist is lost, even if its data
+// was synced. Crash returns the state in which only synced changes
+// persisted, CrashStates enumerates all states permitted by the rules
+// above.
+//
+// Paths are slash or OS separated, relative paths are resolved against the
+// root and volume names are ignored. Permissions are not enforced.
+type CrashFS struct {
+	mu     sync.Mutex
+	specs  map[int]*crashNode // initial state of the files, by inode number
+	nodes  map[int]*crashNode // current state of the files, by inode number
+	ops    []*crashOp         // changes since the file system was created
+	nextID int
+}
+
+// crashRootID is the inode number of the root directory.
+const crashRootID = 1
+
+// maxSymlinks is the number of symbolic links followed when resolving a
+// path before it fails with ELOOP.
+const maxSymlinks = 40
+
+type crashNode struct {
+	mode     os.FileMode
+	uid, gid int
+	modTime  time.Time
+	data     []byte         // contents of a regular file
+	entries  map[string]int // entries of a directory
+	target   string         // target of a symbolic link
+}
+
+func (n *crashNode) clone() *crashNode {
+	c := *n
+	if n.data != nil {
+		c.data = append([]byte(nil), n.data...)
+	}
+	if n.entries != nil {
+		c.entries = make(map[string]int, len(n.entries))
+		for name, id := range n.entries {
+			c.entries[name] = id
+		}
+	}
+	return &c
+}
+
+// A crashOp is a change of a CrashFS. It is either a change of metadata,
+// directory entries or the attributes of a file, or of the data of a file.
+type crashOp struct {
+	durable bool
+
+	entries []crashEntry // changes of directory entries, made atomically
+	attr    *crashAttr   // change of the attributes of a file
+
+	data bool      // change of the data of file ino
+	ino  int       // file whose data changes
+	buf  []byte    // data written at off
+	off  int64     // offset of the write
+	size int64     // new size of a truncation, -1 for writes
+	time time.Time // modification time
+}
+
+type crashEntry struct {
+	dir  int
+	name string
+	ino  int // 0 removes the entry
+}
+
+type crashAttr struct {
+	ino      int
+	mode     os.FileMode
+	uid, gid int
+	modTime  time.Time
+}
+
+// NewCrashFS returns an empty CrashFS.
+func NewCrashFS() *CrashFS {
+	root := &crashNode{
+		mode:    os.ModeDir | 0755,
+		modTime: time.Now(),
+		entries: make(map[string]int),
+	}
+	return &CrashFS{
+		specs:  map[int]*crashNode{crashRootID: root},
+		nodes:  map[int]*crashNode{crashRootID: root.clone()},
+		nextID: crashRootID + 1,
+	}
+}
+
+// apply applies the change op to the files nodes. Files are created in
+// their initial state, from specs, by the first change referring to them,
+// so that the changes persist even if the change creating the file, such
+// as its directory entry in a temporary directory, did not.
+func (op *crashOp) apply(nodes, specs map[int]*crashNode) {
+	node := func(ino int) *crashNode {
+		n := nodes[ino]
+		if n == nil && specs[ino] != nil {
+			n = specs[ino].clone()
+			nodes[ino] = n
+		}
+		return n
+	}
+	for _, e := range op.entries {
+		dir := node(e.dir)
+		if dir == nil {
+			continue
+		}
+		if e.ino == 0 {
+			delete(dir.entries, e.name)
+			continue
+		}
+		if node(e.ino) != nil {
+			dir.entries[e.name] = e.ino
+		}
+	}
+	if a := op.attr; a != nil {
+		if n := node(a.ino); n != nil {
+			n.mode = n.mode&os.ModeType | a.mode&^os.ModeType
+			n.uid, n.gid = a.uid, a.gid
+			n.modTime = a.modTime
+		}
+	}
+	if op.data {
+		n := node(op.ino)
+		if n == nil {
+			return
+		}
+		if op.size >= 0 {
+			n.data = resize(n.data, op.size)
+		} else {
+			if end := op.off + int64(len(op.buf)); end > int64(len(n.data)) {
+				n.data = resize(n.data, end)
+			}
+			copy(n.data[op.off:], op.buf)
+		}
+		n.modTime = op.time
+	}
+}
+
+// resize returns b with the length size, new bytes are zero.
+func resize(b []byte, size int64) []byte {
+	n := int64(len(b))
+	switch {
+	case size <= n:
+		return b[:size]
+	case size <= int64(cap(b)):
+		b = b[:size]
+		for i := n; i < size; i++ {
+			b[i] = 0
+		}
+		return b
+	}
+	return append(b, make([]byte, size-n)...)
+}
+
+// record makes the change op, c.mu must be held.
+func (c *CrashFS) record(op *crashOp) {
+	c.ops = append(c.ops, op)
+	op.apply(c.nodes, c.specs)
+}
+
+// sync makes the volatile changes of file ino durable, c.mu must be held.
+// As metadata changes persist in order, the metadata changes made before
+// the last one synced become durable too.
+func (c *CrashFS) sync(ino int) {
+	isDir := c.nodes[ino].mode.IsDir()
+	last := -1 // last metadata change synced
+	for i, op := range c.ops {
+		if op.durable {
+			continue
+		}
+		switch {
+		case op.data:
+			op.durable = op.ino == ino
+		case op.attr != nil:
+			if op.attr.ino == ino {
+				op.durable = true
+				last = i
+			}
+		case isDir:
+			for _, e := range op.entries {
+				if e.dir == ino {
+					op.durable = true
+					last = i
+					break
+				}
+			}
+		}
+	}
+	for _, op := range c.ops[:last+1] {
+		if !op.data {
+			op.durable = true
+		}
+	}
+}
+
+// newID returns the inode number of a new file with the initial state n,
+// c.mu must be held.
+func (c *CrashFS) newID(n *crashNode) int {
+	id := c.nextID
+	c.nextID++
+	c.specs[id] = n
+	return id
+}
+
+// splitCrashPath returns the elements of name, without empty and "."
+// elements. ".." elements are kept, they are resolved after the symbolic
+// links preceding them.
+func splitCrashPath(name string) ([]string, error) {
+	if name == "" {
+		return nil, syscall.ENOENT
+	}
+	var elems []string
+	for _, e := range strings.Split(filepath.ToSlash(name[len(filepath.VolumeName(name)):]), "/") {
+		if e != "" && e != "." {
+			elems = append(elems, e)
+		}
+	}
+	return elems, nil
+}
+
+// hasTrailingSlash reports whether name ends in a path separator, which
+// requires it to be a directory.
+func hasTrailingSlash(name string) bool {
+	return len(name) > 1 && os.IsPathSeparator(name[len(name)-1])
+}
+
+// resolve returns the directory containing the named file, the name of the
+// file in it and its inode number, which is 0 if the file does not exist.
+// A symbolic link in the last element of name is followed if follow is
+// set. If name refers to the root, or another directory by a path ending
+// in "..", base is empty and dir and ino are the directory. c.mu must be
+// held.
+func (c *CrashFS) resolve(name string, follow bool) (dir int, base string, ino int, err error) {
+	elems, err := splitCrashPath(name)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	stack := []int{crashRootID}
+	links := 0
+	for len(elems) > 0 {
+		elem := elems[0]
+		elems = elems[1:]
+		cur := stack[len(stack)-1]
+		if !c.nodes[cur].mode.IsDir() {
+			return 0, "", 0, syscall.ENOTDIR
+		}
+		switch elem {
+		case ".":
+			continue
+		case "..":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		child := c.nodes[cur].entries[elem]
+		last := len(elems) == 0
+		if child != 0 && c.nodes[child].mode&os.ModeSymlink != 0 && (!last || follow) {
+			if links++; links > maxSymlinks {
+				return 0, "", 0, errLoop
+			}
+			target := c.nodes[child].target
+			if target == "" {
+				return 0, "", 0, syscall.ENOENT
+			}
+			if strings.HasPrefix(target, "/") {
+				stack = stack[:1]
+			}
+			var expanded []string
+			for _, e := range strings.Split(target, "/") {
+				if e != "" {
+					expanded = append(expanded, e)
+				}
+			}
+			elems = append(expanded, elems...)
+			continue
+		}
+		if last {
+			return cur, elem, child, nil
+		}
+		if child == 0 {
+			return 0, "", 0, syscall.ENOENT
+		}
+		stack = append(stack, child)
+	}
+	cur := stack[len(stack)-1]
+	return cur, "", cur, nil
+}
+
+// lookup returns the inode number of the existing file name, c.mu must be
+// held.
+func (c *CrashFS) lookup(name string, follow bool) (int, error) {
+	_, _, ino, err := c.resolve(name, follow)
+	if err != nil {
+		return 0, err
+	}
+	if ino == 0 {
+		return 0, syscall.ENOENT
+	}
+	if hasTrailingSlash(name) && !c.nodes[ino].mode.IsDir() {
+		return 0, syscall.ENOTDIR
+	}
+	return ino, nil
+}
+
+// setAttr records a change of the attributes of file ino made by fn, c.mu
+// must be held.
+func (c *CrashFS) setAttr(ino int, fn func(a *crashAttr)) {
+	n := c.nodes[ino]
+	a := &crashAttr{ino: ino, mode: n.mode, uid: n.uid, gid: n.gid, modTime: n.modTime}
+	fn(a)
+	c.record(&crashOp{attr: a})
+}
+
+func (c *CrashFS) chattr(op, name string, follow bool, fn func(a *crashAttr)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ino, err := c.lookup(name, follow)
+	if err != nil {
+		return &os.PathError{Op: op, Path: name, Err: err}
+	}
+	c.setAttr(ino, fn)
+	return nil
+}
+
+func (c *CrashFS) Chmod(name string, mode os.FileMode) error {
+	return c.chattr("chmod", name, true, func(a *crashAttr) { a.mode = mode })
+}
+
+func (c *CrashFS) Chown(name string, uid, gid int) error {
+	return c.chattr("chown", name, true, func(a *crashAttr) { a.uid, a.gid = uid, gid })
+}
+
+func (c *CrashFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return c.chattr("chtimes", name, true, func(a *crashAttr) { a.modTime = mtime })
+}
+
+// Lchmod changes the mode of the named file, the mode of symbolic links
+// can not be changed.
+func (c *CrashFS) Lchmod(name string, mode os.FileMode) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ino, err := c.lookup(name, false)
+	if err != nil {
+		return &os.PathError{Op: "lchmod", Path: name, Err: err}
+	}
+	if c.nodes[ino].mode&os.ModeSymlink != 0 {
+		return &os.PathError{Op: "lchmod", Path: name, Err: ErrNotSupported}
+	}
+	c.setAttr(ino, func(a *crashAttr) { a.mode = mode })
+	return nil
+}
+
+func (c *CrashFS) Lchown(name string, uid, gid int) error {
+	return c.chattr("lchown", name, false, func(a *crashAttr) { a.uid, a.gid = uid, gid })
+}
+
+func (c *CrashFS) Link(oldname, newname string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ino, err := c.lookup(oldname, false)
+	if err == nil && c.nodes[ino].mode.IsDir() {
+		err = syscall.EPERM
+	}
+	if err != nil {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: err}
+	}
+	dir, base, exists, err := c.resolve(newname, false)
+	if err == nil && (exists != 0 || base == "") {
+		err = syscall.EEXIST
+	}
+	if err != nil {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: err}
+	}
+	c.record(&crashOp{entries: []crashEntry{{dir: dir, name: base, ino: ino}}})
+	return nil
+}
+
+// create records the creation of the file name with the initial state n,
+// c.mu must be held.
+func (c *CrashFS) create(op, name string, n *crashNode) (int, error) {
+	dir, base, ino, err := c.resolve(name, false)
+	if err == nil && (ino != 0 || base == "") {
+		err = syscall.EEXIST
+	}
+	if err != nil {
+		return 0, &os.PathError{Op: op, Path: name, Err: err}
+	}
+	id := c.newID(n)
+	c.record(&crashOp{entries: []crashEntry{{dir: dir, name: base, ino: id}}})
+	return id, nil
+}
+
+func (c *CrashFS) Mkdir(name string, perm os.FileMode) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.create("mkdir", name, &crashNode{
+		mode:    os.ModeDir | perm&^os.ModeType,
+		modTime: time.Now(),
+		entries: make(map[string]int),
+	})
+	return err
+}
+
+// MkdirAll creates the directory path and its missing parents like the
+// function MkdirAll.
+func (c *CrashFS) MkdirAll(path string, perm os.FileMode) error {
+	elems, err := splitCrashPath(path)
+	if err != nil {
+		return &os.PathError{Op: "mkdir", Path: path, Err: err}
+	}
+	for i := range elems {
+		dir := "/" + strings.Join(elems[:i+1], "/")
+		fi, err := c.Stat(dir)
+		if err == nil {
+			if !fi.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: dir, Err: syscall.ENOTDIR}
+			}
+			continue
+		}
+		if err := c.Mkdir(dir, perm); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CrashFS) ReadDir(name string) ([]os.DirEntry, error) {
+	f, err := c.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.ReadDir(-1)
+}
+
+func (c *CrashFS) Readlink(name string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ino, err := c.lookup(name, false)
+	if err == nil && c.nodes[ino].mode&os.ModeSymlink == 0 {
+		err = syscall.EINVAL
+	}
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return c.nodes[ino].target, nil
+}
+
+func (c *CrashFS) Remove(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dir, base, ino, err := c.resolve(name, false)
+	switch {
+	case err != nil:
+	case ino == 0:
+		err = syscall.ENOENT
+	case base == "":
+		err = syscall.EBUSY
+	case c.nodes[ino].mode.IsDir() && len(c.nodes[ino].entries) > 0:
+		err = errNotEmpty
+	}
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	c.record(&crashOp{entries: []crashEntry{{dir: dir, name: base}}})
+	return nil
+}
+
+// RemoveAll removes path and any children it contains like the function
+// RemoveAll. The entries are removed one at a time, from the innermost.
+func (c *CrashFS) RemoveAll(path string) error {
+	if path == "" {
+		return nil
+	}
+	if base := filepath.Base(path); base == "." || base == ".." {
+		return &os.PathError{Op: "RemoveAll", Path: path, Err: syscall.EINVAL}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dir, base, ino, err := c.resolve(path, false)
+	if err != nil || ino == 0 {
+		return nil
+	}
+	if base == "" {
+		return &os.PathError{Op: "RemoveAll", Path: path, Err: syscall.EBUSY}
+	}
+	c.removeAll(dir, base, ino)
+	return nil
+}
+
+func (c *CrashFS) removeAll(dir int, name string, ino int) {
+	if n := c.nodes[ino]; n.mode.IsDir() {
+		for _, child := range sortedEntries(n) {
+			c.removeAll(ino, child, n.entries[child])
+		}
+	}
+	c.record(&crashOp{entries: []crashEntry{{dir: dir, name: name}}})
+}
+
+// sortedEntries returns the names of the entries of the directory n.
+func sortedEntries(n *crashNode) []string {
+	names := make([]string, 0, len(n.entries))
+	for name := range n.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *CrashFS) Rename(oldpath, newpath string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.rename(oldpath, newpath); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	return nil
+}
+
+func (c *CrashFS) rename(oldpath, newpath string) error {
+	oldDir, oldBase, ino, err := c.resolve(oldpath, false)
+	if err != nil {
+		return err
+	}
+	if ino == 0 {
+		return syscall.ENOENT
+	}
+	newDir, newBase, dst, err := c.resolve(newpath, false)
+	if err != nil {
+		return err
+	}
+	if oldBase == "" || newBase == "" {
+		return syscall.EBUSY
+	}
+	if dst == ino {
+		return nil
+	}
+	src := c.nodes[ino]
+	if dst != 0 {
+		d := c.nodes[dst]
+		switch {
+		case src.mode.IsDir() && !d.mode.IsDir():
+			return syscall.ENOTDIR
+		case !src.mode.IsDir() && d.mode.IsDir():
+			return syscall.EISDIR
+		case d.mode.IsDir() && len(d.entries) > 0:
+			return errNotEmpty
+		}
+	}
+	if src.mode.IsDir() && c.contains(ino, newDir) {
+		// A directory can not be moved into itself.
+		return syscall.EINVAL
+	}
+	c.record(&crashOp{entries: []crashEntry{
+		{dir: oldDir, name: oldBase},
+		{dir: newDir, name: newBase, ino: ino},
+	}})
+	return nil
+}
+
+// contains reports whether the directory dir is, or contains, the file
+// ino, c.mu must be held.
+func (c *CrashFS) contains(dir, ino int) bool {
+	if dir == ino {
+		return true
+	}
+	for _, child := range c.nodes[dir].entries {
+		if c.nodes[child].mode.IsDir() && c.contains(child, ino) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CrashFS) Symlink(oldname, newname string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.create("symlink", newname, &crashNode{
+		mode:    os.ModeSymlink | 0777,
+		modTime: time.Now(),
+		target:  filepath.ToSlash(oldname),
+	})
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err.(*os.PathError).Err}
+	}
+	return nil
+}
+
+// truncate records the truncation of file ino, c.mu must be held.
+func (c *CrashFS) truncate(ino int, size int64) {
+	c.record(&crashOp{data: true, ino: ino, size: size, time: time.Now()})
+}
+
+func (c *CrashFS) Truncate(name string, size int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ino, err := c.lookup(name, true)
+	switch {
+	case err != nil:
+	case c.nodes[ino].mode.IsDir():
+		err = syscall.EISDIR
+	case size < 0:
+		err = syscall.EINVAL
+	}
+	if err != nil {
+		return &os.PathError{Op: "truncate", Path: name, Err: err}
+	}
+	c.truncate(ino, size)
+	return nil
+}
+
+func (c *CrashFS) Create(name string) (File, error) {
+	return c.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (c *CrashFS) Open(name string) (File, error) {
+	return c.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (c *CrashFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, err := c.openFile(name, flag, perm)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return f, nil
+}
+
+func (c *CrashFS) openFile(name string, flag int, perm os.FileMode) (*crashFile, error) {
+	dir, base, ino, err := c.resolve(name, true)
+	if err != nil {
+		return nil, err
+	}
+	write := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if ino == 0 {
+		if flag&os.O_CREATE == 0 {
+			return nil, syscall.ENOENT
+		}
+		if hasTrailingSlash(name) {
+			return nil, syscall.EISDIR
+		}
+		ino = c.newID(&crashNode{
+			mode:    perm &^ os.ModeType,
+			modTime: time.Now(),
+		})
+		c.record(&crashOp{entries: []crashEntry{{dir: dir, name: base, ino: ino}}})
+	} else {
+		n := c.nodes[ino]
+		switch {
+		case flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+			return nil, syscall.EEXIST
+		case n.mode.IsDir() && write:
+			return nil, syscall.EISDIR
+		case hasTrailingSlash(name) && !n.mode.IsDir():
+			return nil, syscall.ENOTDIR
+		}
+		if flag&os.O_TRUNC != 0 && write && len(n.data) > 0 {
+			c.truncate(ino, 0)
+		}
+	}
+	return &crashFile{fs: c, name: name, ino: ino, flag: flag}, nil
+}
+
+func (c *CrashFS) ReadFile(name string) ([]byte, error) {
+	f, err := c.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func (c *CrashFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	f, err := c.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (c *CrashFS) stat(op, name string, follow bool) (os.FileInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ino, err := c.lookup(name, follow)
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return c.fileInfo(filepath.Base(name), ino), nil
+}
+
+func (c *CrashFS) Lstat(name string) (os.FileInfo, error) {
+	return c.stat("lstat", name, false)
+}
+
+func (c *CrashFS) Stat(name string) (os.FileInfo, error) {
+	return c.stat("stat", name, true)
+}
+
+// fileInfo returns the FileInfo of file ino, c.mu must be held.
+func (c *CrashFS) fileInfo(name string, ino int) os.FileInfo {
+	n := c.nodes[ino]
+	size := int64(len(n.data))
+	if n.mode&os.ModeSymlink != 0 {
+		size = int64(len(n.target))
+	}
+	return &crashFileInfo{name: name, size: size, mode: n.mode, modTime: n.modTime}
+}
+
+// Crash returns the state of the file system after a crash in which none
+// of the volatile changes persisted. c is not modified.
+func (c *CrashFS) Crash() *CrashFS {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keep := make([]bool, len(c.ops))
+	for i, op := range c.ops {
+		keep[i] = op.durable
+	}
+	return c.replay(keep)
+}
+
+// crashPlan returns the indices of the volatile changes of metadata and,
+// by file, of data. c.mu must be held.
+func (c *CrashFS) crashPlan() (meta []int, data [][]int) {
+	byFile := make(map[int][]int)
+	var files []int
+	for i, op := range c.ops {
+		switch {
+		case op.durable:
+		case op.data:
+			if byFile[op.ino] == nil {
+				files = append(files, op.ino)
+			}
+			byFile[op.ino] = append(byFile[op.ino], i)
+		default:
+			meta = append(meta, i)
+		}
+	}
+	sort.Ints(files)
+	for _, ino := range files {
+		data = append(data, byFile[ino])
+	}
+	return meta, data
+}
+
+// NumCrashStates returns the number of states enumerated by CrashStates.
+// It grows exponentially with the number of files with volatile changes,
+// if it does not fit into an int the largest int is returned.
+func (c *CrashFS) NumCrashStates() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	const maxInt = int(^uint(0) >> 1)
+	meta, data := c.crashPlan()
+	n := len(meta) + 1
+	for _, d := range data {
+		if n > maxInt/(len(d)+1) {
+			return maxInt
+		}
+		n *= len(d) + 1
+	}
+	return n
+}
+
+// CrashStates calls fn with each state the file system may be in after a
+// crash, starting with the one returned by Crash, until fn returns an
+// error, which is returned. c may not be modified by fn.
+func (c *CrashFS) CrashStates(fn func(state *CrashFS) error) error {
+	c.mu.Lock()
+	meta, data := c.crashPlan()
+	c.mu.Unlock()
+
+	// The number of volatile metadata changes and of changes of each file
+	// that persist, counted like the digits of a number.
+	counts := make([]int, 1+len(data))
+	limits := make([]int, 1+len(data))
+	limits[0] = len(meta)
+	for i, d := range data {
+		limits[i+1] = len(d)
+	}
+	for {
+		c.mu.Lock()
+		keep := make([]bool, len(c.ops))
+		for i, op := range c.ops {
+			keep[i] = op.durable
+		}
+		for _, i := range meta[:counts[0]] {
+			keep[i] = true
+		}
+		for j, d := range data {
+			for _, i := range d[:counts[j+1]] {
+				keep[i] = true
+			}
+		}
+		state := c.replay(keep)
+		c.mu.Unlock()
+		if err := fn(state); err != nil {
+			return err
+		}
+
+		i := 0
+		for ; i < len(counts); i++ {
+			if counts[i] < limits[i] {
+				counts[i]++
+				break
+			}
+			counts[i] = 0
+		}
+		if i == len(counts) {
+			return nil
+		}
+	}
+}
+
+// replay returns a new CrashFS with the changes of c for which keep is
+// set, all of them durable. c.mu must be held.
+func (c *CrashFS) replay(keep []bool) *CrashFS {
+	s := &CrashFS{
+		specs:  make(map[int]*crashNode, len(c.specs)),
+		nodes:  map[int]*crashNode{crashRootID: c.specs[crashRootID].clone()},
+		nextID: c.nextID,
+	}
+	for id, n := range c.specs {
+		s.specs[id] = n
+	}
+	for i, op := range c.ops {
+		if !keep[i] {
+			continue
+		}
+		op := *op
+		op.durable = true
+		s.record(&op)
+	}
+	return s
+}
+
+type crashFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi *crashFileInfo) Name() string       { return fi.name }
+func (fi *crashFileInfo) Size() int64        { return fi.size }
+func (fi *crashFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *crashFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *crashFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *crashFileInfo) Sys() interface{}   { return nil }
+
+// A crashFile is an open file of a CrashFS.
+type crashFile struct {
+	fs     *CrashFS
+	name   string
+	ino    int
+	flag   int
+	off    int64
+	closed bool
+	names  []string // remaining directory entries, read by ReadDir
+	listed bool     // names was read
+}
+
+func (f *crashFile) readable() bool { return f.flag&(os.O_WRONLY|os.O_RDWR) != os.O_WRONLY }
+func (f *crashFile) writable() bool { return f.flag&(os.O_WRONLY|os.O_RDWR) != 0 }
+
+// check locks the file system and returns the state of the file, or an
+// error if it is closed.
+func (f *crashFile) check(op string) (*crashNode, error) {
+	f.fs.mu.Lock()
+	if f.closed {
+		f.fs.mu.Unlock()
+		return nil, &os.PathError{Op: op, Path: f.name, Err: os.ErrClosed}
+	}
+	return f.fs.nodes[f.ino], nil
+}
+
+func (f *crashFile) Name() string { return f.name }
+
+func (f *crashFile) Read(b []byte) (int, error) {
+	n, err := f.check("read")
+	if err != nil {
+		return 0, err
+	}
+	defer f.fs.mu.Unlock()
+	m, err := f.readAt("read", n, b, f.off)
+	f.off += int64(m)
+	return m, err
+}
+
+func (f *crashFile) ReadAt(b []byte, off int64) (int, error) {
+	n, err := f.check("read")
+	if err != nil {
+		return 0, err
+	}
+	defer f.fs.mu.Unlock()
+	if off < 0 {
+		return 0, &os.PathError{Op: "readat", Path: f.name, Err: syscall.EINVAL}
+	}
+	m, err := f.readAt("read", n, b, off)
+	if err == nil && m < len(b) {
+		err = io.EOF
+	}
+	return m, err
+}
+
+func (f *crashFile) readAt(op string, n *crashNode, b []byte, off int64) (int, error) {
+	switch {
+	case !f.readable():
+		return 0, &os.PathError{Op: op, Path: f.name, Err: errBadFd}
+	case n.mode.IsDir():
+		return 0, &os.PathError{Op: op, Path: f.name, Err: syscall.EISDIR}
+	case off >= int64(len(n.data)):
+		if len(b) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	return copy(b, n.data[off:]), nil
+}
+
+func (f *crashFile) Write(b []byte) (int, error) {
+	n, err := f.check("write")
+	if err != nil {
+		return 0, err
+	}
+	defer f.fs.mu.Unlock()
+	if f.flag&os.O_APPEND != 0 {
+		f.off = int64(len(n.data))
+	}
+	m, err := f.writeAt("write", b, f.off)
+	f.off += int64(m)
+	return m, err
+}
+
+func (f *crashFile) WriteAt(b []byte, off int64) (int, error) {
+	if _, err := f.check("write"); err != nil {
+		return 0, err
+	}
+	defer f.fs.mu.Unlock()
+	if off < 0 || f.flag&os.O_APPEND != 0 {
+		return 0, &os.PathError{Op: "writeat", Path: f.name, Err: syscall.EINVAL}
+	}
+	return f.writeAt("write", b, off)
+}
+
+func (f *crashFile) writeAt(op string, b []byte, off int64) (int, error) {
+	if !f.writable() {
+		return 0, &os.PathError{Op: op, Path: f.name, Err: errBadFd}
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	f.fs.record(&crashOp{
+		data: true,
+		ino:  f.ino,
+		buf:  append([]byte(nil), b...),
+		off:  off,
+		size: -1,
+		time: time.Now(),
+	})
+	return len(b), nil
+}
+
+func (f *crashFile) Seek(offset int64, whence int) (int64, error) {
+	n, err := f.check("seek")
+	if err != nil {
+		return 0, err
+	}
+	defer f.fs.mu.Unlock()
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += int64(len(n.data))
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: syscall.EINVAL}
+	}
+	f.off = offset
+	if offset == 0 {
+		f.names, f.listed = nil, false
+	}
+	return offset, nil
+}
+
+func (f *crashFile) Close() error {
+	if _, err := f.check("close"); err != nil {
+		return err
+	}
+	defer f.fs.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *crashFile) Stat() (os.FileInfo, error) {
+	if _, err := f.check("stat"); err != nil {
+		return nil, err
+	}
+	defer f.fs.mu.Unlock()
+	return f.fs.fileInfo(filepath.Base(f.name), f.ino), nil
+}
+
+func (f *crashFile) Sync() error {
+	if _, err := f.check("sync"); err != nil {
+		return err
+	}
+	defer f.fs.mu.Unlock()
+	f.fs.sync(f.ino)
+	return nil
+}
+
+func (f *crashFile) Truncate(size int64) error {
+	n, err := f.check("truncate")
+	if err != nil {
+		return err
+	}
+	defer f.fs.mu.Unlock()
+	if !f.writable() || n.mode.IsDir() || size < 0 {
+		return &os.PathError{Op: "truncate", Path: f.name, Err: syscall.EINVAL}
+	}
+	f.fs.truncate(f.ino, size)
+	return nil
+}
+
+func (f *crashFile) ReadDir(n int) ([]os.DirEntry, error) {
+	var entries []os.DirEntry
+	err := f.readDir(n, func(name string, ino int) {
+		entries = append(entries, iofs.FileInfoToDirEntry(f.fs.fileInfo(name, ino)))
+	})
+	return entries, err
+}
+
+func (f *crashFile) Readdirnames(n int) ([]string, error) {
+	var names []string
+	err := f.readDir(n, func(name string, ino int) {
+		names = append(names, name)
+	})
+	return names, err
+}
+
+// readDir calls fn with up to n, or all if n <= 0, of the entries of the
+// directory which were not read yet.
+func (f *crashFile) readDir(n int, fn func(name string, ino int)) error {
+	dir, err := f.check("readdirent")
+	if err != nil {
+		return err
+	}
+	defer f.fs.mu.Unlock()
+	if !dir.mode.IsDir() {
+		return &os.PathError{Op: "readdirent", Path: f.name, Err: syscall.ENOTDIR}
+	}
+	if !f.listed {
+		f.names, f.listed = sortedEntries(dir), true
+	}
+	read := 0
+	for len(f.names) > 0 && (n <= 0 || read < n) {
+		name := f.names[0]
+		f.names = f.names[1:]
+		if ino := dir.entries[name]; ino != 0 {
+			fn(name, ino)
+			read++
+		}
+	}
+	if n > 0 && read == 0 {
+		return io.EOF
+	}
+	return nil
+}
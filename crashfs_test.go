@@ -0,0 +1,321 @@
+package fs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+var _ FS = (*CrashFS)(nil)
+
+// crashContents returns the contents of the regular files of c by path,
+// directories map to "/".
+func crashContents(t *testing.T, c *CrashFS) map[string]string {
+	t.Helper()
+	m := make(map[string]string)
+	var walk func(dir string)
+	walk = func(dir string) {
+		entries, err := c.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			name := dir + e.Name()
+			if e.IsDir() {
+				m[name] = "/"
+				walk(name + "/")
+				continue
+			}
+			data, err := c.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m[name] = string(data)
+		}
+	}
+	walk("/")
+	return m
+}
+
+func TestCrashFS(t *testing.T) {
+	c := NewCrashFS()
+	if err := c.MkdirAll("a/b", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteFile("a/b/f", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Symlink("b/f", "a/link"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := c.ReadFile("/a/link"); err != nil || string(data) != "hello" {
+		t.Errorf("ReadFile(link) = %q, %v; want %q", data, err, "hello")
+	}
+	if target, err := c.Readlink("a/link"); err != nil || target != "b/f" {
+		t.Errorf("Readlink = %q, %v; want %q", target, err, "b/f")
+	}
+	fi, err := c.Stat("a/b/f")
+	if err != nil || fi.Name() != "f" || fi.Size() != 5 || fi.Mode() != 0644 {
+		t.Errorf("Stat = %v, %v", fi, err)
+	}
+	if fi, err := c.Lstat("a/link"); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Lstat(link) = %v, %v", fi, err)
+	}
+
+	f, err := c.OpenFile("a/b/f", os.O_RDWR|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(", world"))
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(f); err != nil || string(data) != "hello, world" {
+		t.Errorf("read %q, %v", data, err)
+	}
+	if err := f.Truncate(4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte("x"), 0); err == nil {
+		t.Error("WriteAt with O_APPEND succeeded")
+	}
+	f.Close()
+	if _, err := f.Read(make([]byte, 1)); err == nil {
+		t.Error("Read after Close succeeded")
+	}
+
+	errTests := []struct {
+		name string
+		err  error
+		ok   func(error) bool
+	}{
+		{"Open missing", func() error { _, err := c.Open("missing"); return err }(), os.IsNotExist},
+		{"Mkdir existing", c.Mkdir("a", 0755), os.IsExist},
+		{"Remove non-empty", c.Remove("a"), func(err error) bool { return err != nil }},
+		{"Open file as dir", func() error { _, err := c.Open("a/b/f/"); return err }(), func(err error) bool { return err != nil }},
+		{"Rename into itself", c.Rename("a", "a/b/c"), func(err error) bool { return err != nil }},
+		{"Link dir", c.Link("a", "c"), func(err error) bool { return err != nil }},
+	}
+	for _, test := range errTests {
+		if !test.ok(test.err) {
+			t.Errorf("%s: unexpected error %v", test.name, test.err)
+		}
+	}
+
+	if err := c.Rename("a/b/f", "g"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RemoveAll("a"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"/g": "hell"}
+	if got := crashContents(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %q, want %q", got, want)
+	}
+}
+
+func TestCrashFSUnsynced(t *testing.T) {
+	c := NewCrashFS()
+	if err := c.WriteFile("f", []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := crashContents(t, c.Crash()); len(got) != 0 {
+		t.Errorf("unsynced file survived the crash: %q", got)
+	}
+
+	// The file is synced but not its directory entry.
+	f, err := c.OpenFile("f", os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Sync()
+	f.Close()
+	if got := crashContents(t, c.Crash()); len(got) != 0 {
+		t.Errorf("file without synced directory entry survived the crash: %q", got)
+	}
+
+	if err := syncCrashDir(c, "/"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"/f": "data"}
+	if got := crashContents(t, c.Crash()); !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %q, want %q", got, want)
+	}
+
+	// An unsynced write may persist, or not.
+	if err := c.WriteFile("f", []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	err = c.CrashStates(func(s *CrashFS) error {
+		seen[crashContents(t, s)["/f"]] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The truncation and the write persist in order.
+	for _, data := range []string{"data", "", "new"} {
+		if !seen[data] {
+			t.Errorf("no state with contents %q", data)
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("states with contents %v, want 3", seen)
+	}
+	if n := c.NumCrashStates(); n != 3 {
+		t.Errorf("NumCrashStates = %d, want 3", n)
+	}
+}
+
+// syncCrashDir syncs the directory name of fsys, unlike syncDir it does
+// not depend on the platform supporting it.
+func syncCrashDir(fsys FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// writeAtomic replaces the file name of fsys by writing the temporary file
+// tmp and renaming it, syncing the changes if sync is set.
+func writeAtomic(fsys FS, tmp, name, data string, sync bool) error {
+	f, err := fsys.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte(data)); err != nil {
+		return err
+	}
+	if sync {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := fsys.Rename(tmp, name); err != nil {
+		return err
+	}
+	if sync {
+		return syncCrashDir(fsys, path.Dir(name))
+	}
+	return nil
+}
+
+func TestCrashFSAtomicWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		tmp  string // temporary file
+		sync bool
+	}{
+		{"Sync", "d/f.tmp", true},
+		{"NoSync", "d/f.tmp", false},
+		// The temporary file is created in another directory, which is
+		// not synced.
+		{"TempDir", "tmp/f", true},
+	}
+	for _, test := range tests {
+		c := NewCrashFS()
+		if err := c.Mkdir("d", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeAtomic(c, "d/f.tmp", "d/f", "old", true); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Mkdir("tmp", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeAtomic(c, test.tmp, "d/f", "new", test.sync); err != nil {
+			t.Fatal(err)
+		}
+		states := 0
+		seen := make(map[string]bool)
+		err := c.CrashStates(func(s *CrashFS) error {
+			states++
+			data, err := s.ReadFile("d/f")
+			if err != nil {
+				return err
+			}
+			seen[string(data)] = true
+			if string(data) != "old" && string(data) != "new" {
+				return fmt.Errorf("f contains %q", data)
+			}
+			return nil
+		})
+		if !test.sync {
+			// Without the sync, the rename may persist without the data.
+			if err == nil {
+				t.Errorf("%s: all %d states are consistent without sync", test.name, states)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !seen["new"] || seen["old"] {
+			t.Errorf("%s: contents after sync %v, want only new", test.name, seen)
+		}
+		if states != c.NumCrashStates() {
+			t.Errorf("%s: CrashStates called fn %d times, NumCrashStates = %d", test.name, states, c.NumCrashStates())
+		}
+	}
+}
+
+func TestCrashFSMetadataOrder(t *testing.T) {
+	c := NewCrashFS()
+	for _, name := range []string{"a", "b", "b/f"} {
+		if err := c.Mkdir(name, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Syncing b also persists the creation of a, made before.
+	if err := syncCrashDir(c, "b"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"/a": "/", "/b": "/", "/b/f": "/"}
+	err := c.CrashStates(func(s *CrashFS) error {
+		if got := crashContents(t, s); !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("contents = %q, want %q", got, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if n := c.NumCrashStates(); n != 1 {
+		t.Errorf("NumCrashStates = %d, want 1", n)
+	}
+}
+
+func TestCrashFSDotDot(t *testing.T) {
+	c := NewCrashFS()
+	if err := c.MkdirAll("a/b/c", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Symlink("b/c", "a/link"); err != nil {
+		t.Fatal(err)
+	}
+	// ".." is resolved after the link, as by the kernel.
+	if err := c.WriteFile("a/link/../x", []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Stat("a/b/x"); err != nil {
+		t.Errorf("Stat(a/b/x): %v", err)
+	}
+	if _, err := c.Stat("a/x"); !os.IsNotExist(err) {
+		t.Errorf("Stat(a/x): error = %v, want not exist", err)
+	}
+	if _, err := c.Stat("a/b/x/.."); err == nil {
+		t.Error("Stat(a/b/x/..) of a file succeeded")
+	}
+	if fi, err := c.Stat("a/b/c/../../.."); err != nil || !fi.IsDir() {
+		t.Errorf("Stat(a/b/c/../../..) = %v, %v; want the root", fi, err)
+	}
+}
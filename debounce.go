@@ -0,0 +1,264 @@
+package fs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultDebounceWindow is the default quiet period of a Debouncer.
+const DefaultDebounceWindow = 100 * time.Millisecond
+
+// DebounceOptions configure a Debouncer.
+type DebounceOptions struct {
+	// Window is how long a Debouncer waits for more events after the last
+	// one before emitting a batch. If zero, DefaultDebounceWindow is used.
+	Window time.Duration
+
+	// MaxDelay bounds how long the first event of a batch may be held
+	// back when events keep arriving. If zero, there is no bound.
+	MaxDelay time.Duration
+
+	// Clock is used to measure the window. If nil, SystemClock is used.
+	Clock Clock
+}
+
+// A Debouncer coalesces the events of a Watcher into batches. A batch is
+// emitted once no event was received for the duration of the window and
+// contains at most one event per file, in the order the files were first
+// changed.
+//
+// Events for the same file are merged as follows:
+//
+//   - A file created and then removed is not reported.
+//   - A file removed and then created again is reported as written.
+//   - A file created and then modified is reported as created.
+//   - A file renamed several times is reported as a single rename from its
+//     original name, a file renamed back to its original name is only
+//     reported if it was modified.
+//   - A file created and then renamed, as done by editors and other tools
+//     that write to a temporary file before renaming it over the target,
+//     is reported as a write to its final name. If the target was created
+//     within the window, it is reported as created.
+type Debouncer struct {
+	w       Watcher
+	opts    DebounceOptions
+	batches chan []Event
+	errors  chan error
+	done    chan struct{}
+	exited  chan struct{}
+	once    sync.Once
+
+	// The below fields are only accessed by the run goroutine.
+	pending map[string]*pollEvent
+	seq     int
+}
+
+// NewDebouncer returns a Debouncer for the events of w. The Debouncer takes
+// ownership of w, which is closed when the Debouncer is closed.
+func NewDebouncer(w Watcher, opts *DebounceOptions) *Debouncer {
+	d := &Debouncer{
+		w:       w,
+		batches: make(chan []Event),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+		pending: make(map[string]*pollEvent),
+	}
+	if opts != nil {
+		d.opts = *opts
+	}
+	if d.opts.Window <= 0 {
+		d.opts.Window = DefaultDebounceWindow
+	}
+	if d.opts.Clock == nil {
+		d.opts.Clock = SystemClock
+	}
+	go d.run()
+	return d
+}
+
+// Batches returns the channel on which batches of events are delivered. It
+// is closed when the Debouncer is closed or the events of the underlying
+// Watcher are exhausted.
+func (d *Debouncer) Batches() <-chan []Event { return d.batches }
+
+// Errors returns the channel on which the errors of the underlying Watcher
+// are delivered.
+func (d *Debouncer) Errors() <-chan error { return d.errors }
+
+// Close closes the underlying Watcher and the Batches and Errors channels.
+// Pending events are discarded.
+func (d *Debouncer) Close() error {
+	var err error
+	d.once.Do(func() {
+		close(d.done)
+		err = d.w.Close()
+		<-d.exited
+	})
+	return err
+}
+
+func (d *Debouncer) run() {
+	defer func() {
+		close(d.batches)
+		close(d.errors)
+		close(d.exited)
+	}()
+
+	var (
+		timer    <-chan time.Time
+		deadline time.Time // time at which the pending batch is emitted
+		first    time.Time // time of the first pending event
+	)
+	events, errors := d.w.Events(), d.w.Errors()
+	for events != nil || errors != nil {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			now := d.opts.Clock.Now()
+			if len(d.pending) == 0 {
+				first = now
+			}
+			d.add(e)
+			deadline = now.Add(d.opts.Window)
+			if d.opts.MaxDelay > 0 && deadline.Sub(first) > d.opts.MaxDelay {
+				deadline = first.Add(d.opts.MaxDelay)
+			}
+			if timer == nil && len(d.pending) > 0 {
+				timer = d.opts.Clock.After(deadline.Sub(now))
+			}
+		case err, ok := <-errors:
+			if !ok {
+				errors = nil
+				continue
+			}
+			select {
+			case d.errors <- err:
+			case <-d.done:
+				return
+			}
+		case now := <-timer:
+			timer = nil
+			if len(d.pending) == 0 {
+				continue
+			}
+			if now.Before(deadline) {
+				timer = d.opts.Clock.After(deadline.Sub(now))
+				continue
+			}
+			if !d.flush() {
+				return
+			}
+		case <-d.done:
+			return
+		}
+	}
+	// The Watcher was closed, emit what is left.
+	d.flush()
+}
+
+// flush emits the pending events, it returns false if the Debouncer was
+// closed.
+func (d *Debouncer) flush() bool {
+	if len(d.pending) == 0 {
+		return true
+	}
+	batch := make([]*pollEvent, 0, len(d.pending))
+	for _, p := range d.pending {
+		batch = append(batch, p)
+	}
+	d.pending = make(map[string]*pollEvent)
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].seq < batch[j].seq
+	})
+	events := make([]Event, len(batch))
+	for i, p := range batch {
+		events[i] = p.Event
+	}
+	select {
+	case d.batches <- events:
+		return true
+	case <-d.done:
+		return false
+	}
+}
+
+// add merges e into the pending events.
+func (d *Debouncer) add(e Event) {
+	if e.Op&OpRename != 0 && e.OldName != "" {
+		d.rename(e)
+		return
+	}
+	p, ok := d.pending[e.Name]
+	if !ok {
+		d.seq++
+		d.pending[e.Name] = &pollEvent{Event: e, seq: d.seq}
+		return
+	}
+	switch {
+	case p.Op&OpCreate != 0 && e.Op&OpRemove != 0 && e.Op&OpCreate == 0:
+		delete(d.pending, e.Name)
+	case p.Op&OpCreate != 0:
+		// Reporting the creation is sufficient.
+	case p.Op&OpRemove != 0 && e.Op&OpCreate != 0:
+		p.Op = OpWrite
+	case e.Op&OpRemove != 0:
+		if p.Op&OpRename != 0 {
+			// The file was renamed and then removed, report the removal
+			// of its original name.
+			delete(d.pending, e.Name)
+			p.Event = Event{Name: p.OldName, Op: OpRemove}
+			d.pending[p.Name] = p
+			return
+		}
+		p.Op = OpRemove
+	default:
+		p.Op |= e.Op
+	}
+}
+
+// rename merges the rename e into the pending events.
+func (d *Debouncer) rename(e Event) {
+	src, hasSrc := d.pending[e.OldName]
+	dst, hasDst := d.pending[e.Name]
+	delete(d.pending, e.OldName)
+
+	p := &pollEvent{Event: e}
+	if hasSrc {
+		p.seq = src.seq
+	} else {
+		d.seq++
+		p.seq = d.seq
+	}
+	switch {
+	case hasSrc && src.Op&OpCreate != 0:
+		// A new file moved into place.
+		p.OldName = ""
+		p.Op = OpWrite
+		if hasDst && dst.Op&OpCreate != 0 {
+			p.Op = OpCreate
+		}
+	case hasSrc && src.Op&OpRename != 0:
+		p.OldName = src.OldName
+		p.Op = src.Op | OpRename
+		if p.OldName == p.Name {
+			// Renamed back to its original name.
+			p.OldName = ""
+			p.Op &^= OpRename
+			if p.Op == 0 {
+				delete(d.pending, e.Name)
+				return
+			}
+		}
+	case hasSrc:
+		p.Op = src.Op | OpRename
+	}
+	if hasDst && dst.seq < p.seq {
+		p.seq = dst.seq
+	}
+	d.pending[p.Name] = p
+}
@@ -0,0 +1,247 @@
+package fs
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// chanWatcher is a Watcher whose events are sent by the test.
+type chanWatcher struct {
+	events chan Event
+	errors chan error
+}
+
+func newChanWatcher() *chanWatcher {
+	return &chanWatcher{events: make(chan Event), errors: make(chan error)}
+}
+
+func (w *chanWatcher) Events() <-chan Event { return w.events }
+func (w *chanWatcher) Errors() <-chan error { return w.errors }
+func (w *chanWatcher) Close() error         { return nil }
+
+const testWindow = 100 * time.Millisecond
+
+// debounce sends events to a Debouncer and returns the batch it emits once
+// the events are exhausted.
+func debounce(t *testing.T, events ...Event) []Event {
+	t.Helper()
+	w := newChanWatcher()
+	d := NewDebouncer(w, &DebounceOptions{Window: time.Hour, Clock: newFakeClock()})
+	defer d.Close()
+	for _, e := range events {
+		w.events <- e
+	}
+	close(w.events)
+	close(w.errors)
+	return <-d.Batches()
+}
+
+func TestDebouncerCoalesce(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+		want   []Event
+	}{
+		{
+			"Writes",
+			[]Event{{Name: "a", Op: OpWrite}, {Name: "b", Op: OpWrite}, {Name: "a", Op: OpWrite}},
+			[]Event{{Name: "a", Op: OpWrite}, {Name: "b", Op: OpWrite}},
+		},
+		{
+			"WriteChmod",
+			[]Event{{Name: "a", Op: OpWrite}, {Name: "a", Op: OpChmod}},
+			[]Event{{Name: "a", Op: OpWrite | OpChmod}},
+		},
+		{
+			"CreateWrite",
+			[]Event{{Name: "a", Op: OpCreate}, {Name: "a", Op: OpWrite}, {Name: "a", Op: OpChmod}},
+			[]Event{{Name: "a", Op: OpCreate}},
+		},
+		{
+			"CreateRemove",
+			[]Event{{Name: "a", Op: OpCreate}, {Name: "b", Op: OpWrite}, {Name: "a", Op: OpRemove}},
+			[]Event{{Name: "b", Op: OpWrite}},
+		},
+		{
+			"RemoveCreate",
+			[]Event{{Name: "a", Op: OpRemove}, {Name: "a", Op: OpCreate}},
+			[]Event{{Name: "a", Op: OpWrite}},
+		},
+		{
+			"WriteRemove",
+			[]Event{{Name: "a", Op: OpWrite}, {Name: "a", Op: OpRemove}},
+			[]Event{{Name: "a", Op: OpRemove}},
+		},
+		{
+			"AtomicSave",
+			[]Event{
+				{Name: "a.tmp", Op: OpCreate},
+				{Name: "a.tmp", Op: OpWrite},
+				{Name: "a", OldName: "a.tmp", Op: OpRename},
+			},
+			[]Event{{Name: "a", Op: OpWrite}},
+		},
+		{
+			"AtomicCreate",
+			[]Event{
+				{Name: "a", Op: OpCreate},
+				{Name: "a.tmp", Op: OpCreate},
+				{Name: "a", OldName: "a.tmp", Op: OpRename},
+			},
+			[]Event{{Name: "a", Op: OpCreate}},
+		},
+		{
+			"RenameChain",
+			[]Event{
+				{Name: "b", OldName: "a", Op: OpRename},
+				{Name: "b", Op: OpWrite},
+				{Name: "c", OldName: "b", Op: OpRename},
+			},
+			[]Event{{Name: "c", OldName: "a", Op: OpRename | OpWrite}},
+		},
+		{
+			"RenameBack",
+			[]Event{
+				{Name: "b", OldName: "a", Op: OpRename},
+				{Name: "a", OldName: "b", Op: OpRename},
+				{Name: "c", Op: OpCreate},
+			},
+			[]Event{{Name: "c", Op: OpCreate}},
+		},
+		{
+			"RenameRemove",
+			[]Event{
+				{Name: "b", OldName: "a", Op: OpRename},
+				{Name: "b", Op: OpRemove},
+			},
+			[]Event{{Name: "a", Op: OpRemove}},
+		},
+		{
+			"WriteRename",
+			[]Event{
+				{Name: "a", Op: OpWrite},
+				{Name: "b", OldName: "a", Op: OpRename},
+			},
+			[]Event{{Name: "b", OldName: "a", Op: OpWrite | OpRename}},
+		},
+	}
+	for _, test := range tests {
+		got := debounce(t, test.events...)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\ngot:  %v\nwant: %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDebouncerWindow(t *testing.T) {
+	w := newChanWatcher()
+	clock := newFakeClock()
+	d := NewDebouncer(w, &DebounceOptions{
+		Window:   testWindow,
+		MaxDelay: 3 * testWindow,
+		Clock:    clock,
+	})
+	defer d.Close()
+
+	expectNoBatch := func() {
+		t.Helper()
+		select {
+		case b := <-d.Batches():
+			t.Fatalf("unexpected batch: %v", b)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	w.events <- Event{Name: "a", Op: OpWrite}
+	if !clock.waitTimer(watchTimeout) {
+		t.Fatal("timer not started")
+	}
+	// Each event restarts the window.
+	for i := 0; i < 2; i++ {
+		clock.Advance(testWindow / 2)
+		w.events <- Event{Name: "a", Op: OpWrite}
+		expectNoBatch()
+	}
+	clock.Advance(testWindow / 2)
+	expectNoBatch()
+	clock.Advance(testWindow / 2)
+	if b := <-d.Batches(); !reflect.DeepEqual(b, []Event{{Name: "a", Op: OpWrite}}) {
+		t.Fatalf("batch: %v", b)
+	}
+
+	// Continuous events are emitted after MaxDelay.
+	for i := 0; i < 6; i++ {
+		w.events <- Event{Name: "b", Op: OpWrite}
+		if !clock.waitTimer(watchTimeout) {
+			t.Fatal("timer not started")
+		}
+		clock.Advance(testWindow / 2)
+	}
+	if b := <-d.Batches(); !reflect.DeepEqual(b, []Event{{Name: "b", Op: OpWrite}}) {
+		t.Fatalf("batch: %v", b)
+	}
+}
+
+func TestDebouncerErrors(t *testing.T) {
+	w := newChanWatcher()
+	d := NewDebouncer(w, nil)
+	w.errors <- ErrEventOverflow
+	if err := <-d.Errors(); err != ErrEventOverflow {
+		t.Errorf("Errors: got: %v want: %v", err, ErrEventOverflow)
+	}
+	// The pending events are emitted when the Watcher is exhausted.
+	w.events <- Event{Name: "a", Op: OpCreate}
+	close(w.events)
+	close(w.errors)
+	if b := <-d.Batches(); !reflect.DeepEqual(b, []Event{{Name: "a", Op: OpCreate}}) {
+		t.Errorf("batch: %v", b)
+	}
+	if _, ok := <-d.Batches(); ok {
+		t.Error("Batches not closed")
+	}
+	d.Close()
+}
+
+// Test an editor style save, through a temporary file, with a real Watcher.
+func TestDebouncerWatcher(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("native watcher not supported on %s", runtime.GOOS)
+	}
+	root := newDir("TestDebouncerWatcher", t)
+	defer RemoveAll(root)
+	name := filepath.Join(root, "a.txt")
+	if err := ioutil.WriteFile(name, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDebouncer(w, &DebounceOptions{Window: 50 * time.Millisecond})
+	defer d.Close()
+
+	tmp := filepath.Join(root, ".a.txt.swp")
+	f, err := Create(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("new")
+	f.Close()
+	if err := Rename(tmp, name); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case b := <-d.Batches():
+		if want := []Event{{Name: name, Op: OpWrite}}; !reflect.DeepEqual(b, want) {
+			t.Errorf("batch:\ngot:  %v\nwant: %v", b, want)
+		}
+	case err := <-d.Errors():
+		t.Fatal(err)
+	case <-time.After(watchTimeout):
+		t.Fatal("timed out waiting for batch")
+	}
+}
@@ -0,0 +1,18 @@
+package fs
+
+// Makedev returns the device number with the major and minor numbers, as
+// encoded by the platform. It is the inverse of Major and Minor.
+func Makedev(major, minor uint32) uint64 {
+	return makedev(major, minor)
+}
+
+// Major returns the major number of the device number dev, e.g. of the
+// Rdev field of a syscall.Stat_t.
+func Major(dev uint64) uint32 {
+	return devMajor(dev)
+}
+
+// Minor returns the minor number of the device number dev.
+func Minor(dev uint64) uint32 {
+	return devMinor(dev)
+}
@@ -0,0 +1,13 @@
+package fs
+
+func makedev(major, minor uint32) uint64 {
+	return uint64(major)<<24 | uint64(minor&0xffffff)
+}
+
+func devMajor(dev uint64) uint32 {
+	return uint32(dev>>24) & 0xff
+}
+
+func devMinor(dev uint64) uint32 {
+	return uint32(dev & 0xffffff)
+}
@@ -0,0 +1,21 @@
+//go:build !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package fs
+
+// The encoding of device numbers used by Linux, which is also used on
+// platforms without device numbers of their own.
+
+func makedev(major, minor uint32) uint64 {
+	return uint64(major&0xfff)<<8 | uint64(major&^0xfff)<<32 |
+		uint64(minor&0xff) | uint64(minor&^0xff)<<12
+}
+
+// devMajor and devMinor split a device number, as encoded by the kernel.
+func devMajor(dev uint64) uint32 {
+	return uint32((dev>>8)&0xfff | (dev>>32)&^0xfff)
+}
+
+func devMinor(dev uint64) uint32 {
+	return uint32(dev&0xff | (dev>>12)&^0xff)
+}
@@ -0,0 +1,14 @@
+package fs
+
+func makedev(major, minor uint32) uint64 {
+	return uint64(major&0xffffff00)<<32 | uint64(major&0xff)<<8 |
+		uint64(minor&0xff00)<<24 | uint64(minor&0xffff00ff)
+}
+
+func devMajor(dev uint64) uint32 {
+	return uint32((dev>>32)&0xffffff00 | (dev>>8)&0xff)
+}
+
+func devMinor(dev uint64) uint32 {
+	return uint32((dev>>24)&0xff00 | dev&0xffff00ff)
+}
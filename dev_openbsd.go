@@ -0,0 +1,14 @@
+package fs
+
+func makedev(major, minor uint32) uint64 {
+	return uint64(major)<<8&0x0000ff00 | uint64(minor)<<8&0xffff0000 |
+		uint64(minor)&0x000000ff
+}
+
+func devMajor(dev uint64) uint32 {
+	return uint32(dev&0x0000ff00) >> 8
+}
+
+func devMinor(dev uint64) uint32 {
+	return uint32(dev&0x000000ff) | uint32(dev&0xffff0000)>>8
+}
@@ -0,0 +1,172 @@
+// The below code uses portions of the Go standard library.
+// The full license can be found in fs.go.
+//
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import (
+	"errors"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DirFS returns a file system (an fs.FS) for the tree of files rooted at
+// the directory dir, like os.DirFS, whose files are accessed with this
+// package and can therefore be deeper than MAX_PATH on Windows.
+//
+// The result implements fs.StatFS, fs.ReadFileFS and fs.ReadDirFS.
+func DirFS(dir string) iofs.FS {
+	return dirFS(dir)
+}
+
+type dirFS string
+
+func (dir dirFS) Open(name string) (iofs.File, error) {
+	fullname, err := dir.join(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	f, err := Open(fullname)
+	if err != nil {
+		return nil, relPathError(err, name)
+	}
+	return f, nil
+}
+
+func (dir dirFS) ReadFile(name string) ([]byte, error) {
+	fullname, err := dir.join(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	b, err := ReadFile(fullname)
+	if err != nil {
+		return nil, relPathError(err, name)
+	}
+	return b, nil
+}
+
+func (dir dirFS) ReadDir(name string) ([]iofs.DirEntry, error) {
+	fullname, err := dir.join(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	entries, err := ReadDir(fullname)
+	if err != nil {
+		return entries, relPathError(err, name)
+	}
+	return entries, nil
+}
+
+func (dir dirFS) Stat(name string) (iofs.FileInfo, error) {
+	fullname, err := dir.join(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	fi, err := Stat(fullname)
+	if err != nil {
+		return nil, relPathError(err, name)
+	}
+	return fi, nil
+}
+
+// join returns the path of the slash-separated name in dir.
+func (dir dirFS) join(name string) (string, error) {
+	if dir == "" {
+		return "", errors.New("fs: DirFS with empty root")
+	}
+	local, err := localPath(name)
+	if err != nil {
+		return "", err
+	}
+	if local == "." {
+		return string(dir), nil
+	}
+	return joinPath(string(dir), local), nil
+}
+
+// localPath converts the slash-separated name of a file of an fs.FS to an
+// operating system path.
+func localPath(name string) (string, error) {
+	if !iofs.ValidPath(name) {
+		return "", os.ErrInvalid
+	}
+	if runtime.GOOS == "windows" && strings.ContainsAny(name, `\:`) {
+		return "", os.ErrInvalid
+	}
+	return filepath.FromSlash(name), nil
+}
+
+// relPathError replaces the path of err, if it is a *PathError, with the
+// name relative to the root of a DirFS, so that the root is not disclosed.
+func relPathError(err error, name string) error {
+	if pe, ok := err.(*os.PathError); ok {
+		pe.Path = name
+	}
+	return err
+}
+
+// CopyFS copies the file system fsys into the directory dir, creating dir
+// if necessary, like os.CopyFS.
+//
+// Files are created with mode 0o666 plus any execute permissions from the
+// source, and directories are created with mode 0o777 (before umask).
+//
+// CopyFS will not overwrite existing files. If a file name in fsys already
+// exists in the destination, CopyFS will return an error such that
+// errors.Is(err, fs.ErrExist) will be true.
+//
+// Symbolic links and other irregular files in fsys are not supported, for
+// them CopyFS returns an error wrapping fs.ErrInvalid.
+//
+// New files added to fsys (including if dir is a subdirectory of fsys)
+// while CopyFS is running are not guaranteed to be copied.
+//
+// Copying stops at and returns the first error encountered.
+func CopyFS(dir string, fsys iofs.FS) error {
+	return iofs.WalkDir(fsys, ".", func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fpath, err := localPath(path)
+		if err != nil {
+			return &os.PathError{Op: "CopyFS", Path: path, Err: err}
+		}
+		newPath := filepath.Join(dir, fpath)
+
+		switch d.Type() {
+		case iofs.ModeDir:
+			return MkdirAll(newPath, 0777)
+		case 0:
+			return copyFSFile(fsys, path, newPath)
+		}
+		return &os.PathError{Op: "CopyFS", Path: path, Err: os.ErrInvalid}
+	})
+}
+
+func copyFSFile(fsys iofs.FS, path, newPath string) error {
+	r, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	info, err := r.Stat()
+	if err != nil {
+		return err
+	}
+	w, err := OpenFile(newPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666|info.Mode()&0777)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return &os.PathError{Op: "Copy", Path: newPath, Err: err}
+	}
+	return w.Close()
+}
@@ -0,0 +1,92 @@
+package fs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDirFS(t *testing.T) {
+	root := TempTree(t, map[string]string{
+		"a.txt":     "a",
+		"b/c.txt":   "c",
+		"b/d/e.txt": "e",
+		"empty/":    "",
+	})
+	fsys := DirFS(root)
+	if err := fstest.TestFS(fsys, "a.txt", "b/c.txt", "b/d/e.txt", "empty"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Errors must not disclose the root.
+	_, err := fsys.Open("missing")
+	var pe *os.PathError
+	if !errors.As(err, &pe) || pe.Path != "missing" || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(missing): error = %v", err)
+	}
+
+	invalid := []string{"/a.txt", "../a.txt", "b/../a.txt", "b/"}
+	if runtime.GOOS == "windows" {
+		invalid = append(invalid, `b\c.txt`, `c:a.txt`)
+	}
+	for _, name := range invalid {
+		if _, err := fsys.Open(name); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("Open(%q): error = %v, want %v", name, err, fs.ErrInvalid)
+		}
+		if _, err := fs.Stat(fsys, name); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("Stat(%q): error = %v, want %v", name, err, fs.ErrInvalid)
+		}
+	}
+}
+
+func TestCopyFS(t *testing.T) {
+	src := fstest.MapFS{
+		"a.txt":     {Data: []byte("a"), Mode: 0644},
+		"bin/run":   {Data: []byte("#!/bin/sh\n"), Mode: 0755},
+		"b/d/e.txt": {Data: []byte("e"), Mode: 0644},
+		"empty":     {Mode: fs.ModeDir | 0755},
+	}
+	dir := filepath.Join(TempTree(t, nil), "dst")
+	if err := CopyFS(dir, src); err != nil {
+		t.Fatalf("CopyFS: %v", err)
+	}
+	if err := fstest.TestFS(DirFS(dir), "a.txt", "bin/run", "b/d/e.txt", "empty"); err != nil {
+		t.Fatal(err)
+	}
+	for name, f := range src {
+		if f.Mode.IsDir() {
+			continue
+		}
+		got, err := ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(f.Data) {
+			t.Errorf("%s = %q, want %q", name, got, f.Data)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		fi, err := Stat(filepath.Join(dir, "bin", "run"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode()&0100 == 0 {
+			t.Errorf("bin/run: mode %v is not executable", fi.Mode())
+		}
+	}
+
+	// Existing files are not overwritten.
+	if err := CopyFS(dir, src); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("CopyFS into existing tree: error = %v, want %v", err, fs.ErrExist)
+	}
+
+	// Irregular files are rejected.
+	sym := fstest.MapFS{"link": {Data: []byte("a.txt"), Mode: fs.ModeSymlink}}
+	if err := CopyFS(filepath.Join(dir, "sym"), sym); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("CopyFS of symlink: error = %v, want %v", err, fs.ErrInvalid)
+	}
+}
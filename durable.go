@@ -0,0 +1,266 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// SyncDir commits the entries of the named directory, such as the names of
+// files created, renamed or removed in it, to stable storage. Their
+// metadata is only durable once the directory is synced.
+//
+// On Windows directories can not be synced and SyncDir only checks that
+// the directory exists. File systems that do not support syncing
+// directories are ignored.
+// If there is an error, it will be of type *PathError.
+func SyncDir(name string) error {
+	return syncDir(SystemFS, name)
+}
+
+func syncDir(fsys FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !canSyncDir {
+		return nil
+	}
+	if err := f.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
+}
+
+// A DurableFS is an FS that makes the changes it performs durable before
+// they are reported complete: files opened for writing are synced when
+// they are closed, and the parent directories of files created, linked,
+// renamed or removed are synced after the operation.
+//
+// Changes of metadata, such as by Chmod, and writes to files that are not
+// closed are not synced.
+type DurableFS struct {
+	fsys FS
+}
+
+// NewDurableFS returns a DurableFS performing the operations of fsys. If
+// fsys is nil, SystemFS is used.
+func NewDurableFS(fsys FS) *DurableFS {
+	if fsys == nil {
+		fsys = SystemFS
+	}
+	return &DurableFS{fsys: fsys}
+}
+
+// SyncDir syncs the named directory, see the function SyncDir.
+func (d *DurableFS) SyncDir(name string) error {
+	return syncDir(d.fsys, name)
+}
+
+// syncParent syncs the directory containing name.
+func (d *DurableFS) syncParent(name string) error {
+	return d.SyncDir(filepath.Dir(name))
+}
+
+// syncParents syncs the directories containing oldname and newname.
+func (d *DurableFS) syncParents(oldname, newname string) error {
+	oldDir, newDir := filepath.Dir(oldname), filepath.Dir(newname)
+	if err := d.SyncDir(newDir); err != nil {
+		return err
+	}
+	if oldDir != newDir {
+		return d.SyncDir(oldDir)
+	}
+	return nil
+}
+
+// A durableFile is a file opened for writing, which is synced when it is
+// closed.
+type durableFile struct {
+	File
+}
+
+func (f durableFile) Close() error {
+	err := f.File.Sync()
+	if cerr := f.File.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (d *DurableFS) Chmod(name string, mode os.FileMode) error {
+	return d.fsys.Chmod(name, mode)
+}
+
+func (d *DurableFS) Chown(name string, uid, gid int) error {
+	return d.fsys.Chown(name, uid, gid)
+}
+
+func (d *DurableFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return d.fsys.Chtimes(name, atime, mtime)
+}
+
+func (d *DurableFS) Lchmod(name string, mode os.FileMode) error {
+	return d.fsys.Lchmod(name, mode)
+}
+
+func (d *DurableFS) Lchown(name string, uid, gid int) error {
+	return d.fsys.Lchown(name, uid, gid)
+}
+
+func (d *DurableFS) Link(oldname, newname string) error {
+	if err := d.fsys.Link(oldname, newname); err != nil {
+		return err
+	}
+	return d.syncParent(newname)
+}
+
+func (d *DurableFS) Mkdir(name string, perm os.FileMode) error {
+	if err := d.fsys.Mkdir(name, perm); err != nil {
+		return err
+	}
+	return d.syncParent(name)
+}
+
+// MkdirAll creates the directories like the function MkdirAll and syncs
+// the parent of each directory created.
+func (d *DurableFS) MkdirAll(path string, perm os.FileMode) error {
+	// Find the directories that do not exist yet, from the innermost.
+	var missing []string
+	for dir := filepath.Clean(path); ; {
+		if _, err := d.fsys.Stat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if err := d.fsys.MkdirAll(path, perm); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := d.syncParent(missing[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DurableFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return d.fsys.ReadDir(name)
+}
+
+func (d *DurableFS) Readlink(name string) (string, error) {
+	return d.fsys.Readlink(name)
+}
+
+func (d *DurableFS) Remove(name string) error {
+	if err := d.fsys.Remove(name); err != nil {
+		return err
+	}
+	return d.syncParent(name)
+}
+
+// RemoveAll removes path and any children it contains, and syncs the
+// parent unless path did not exist.
+func (d *DurableFS) RemoveAll(path string) error {
+	_, err := d.fsys.Lstat(path)
+	if err := d.fsys.RemoveAll(path); err != nil {
+		return err
+	}
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return d.syncParent(path)
+}
+
+func (d *DurableFS) Rename(oldpath, newpath string) error {
+	if err := d.fsys.Rename(oldpath, newpath); err != nil {
+		return err
+	}
+	return d.syncParents(oldpath, newpath)
+}
+
+func (d *DurableFS) Symlink(oldname, newname string) error {
+	if err := d.fsys.Symlink(oldname, newname); err != nil {
+		return err
+	}
+	return d.syncParent(newname)
+}
+
+// Truncate changes the size of the named file and syncs it through a
+// handle opened for writing, which syncing requires on Windows.
+func (d *DurableFS) Truncate(name string, size int64) error {
+	f, err := d.fsys.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	err = f.Truncate(size)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (d *DurableFS) Create(name string) (File, error) {
+	return d.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (d *DurableFS) Open(name string) (File, error) {
+	return d.fsys.Open(name)
+}
+
+// OpenFile opens the named file like the function OpenFile. If the file is
+// opened for writing, it is synced when it is closed. If it may have been
+// created, its directory is synced.
+func (d *DurableFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := d.fsys.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	if flag&os.O_CREATE != 0 {
+		if err := d.syncParent(name); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return f, nil
+	}
+	return durableFile{f}, nil
+}
+
+func (d *DurableFS) ReadFile(name string) ([]byte, error) {
+	return d.fsys.ReadFile(name)
+}
+
+// WriteFile writes the named file like the function WriteFile and syncs it
+// and its directory.
+func (d *DurableFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	f, err := d.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (d *DurableFS) Lstat(name string) (os.FileInfo, error) {
+	return d.fsys.Lstat(name)
+}
+
+func (d *DurableFS) Stat(name string) (os.FileInfo, error) {
+	return d.fsys.Stat(name)
+}
@@ -0,0 +1,179 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncRecorder is an FS recording the files that are synced.
+type syncRecorder struct {
+	FS
+	root string
+
+	mu     sync.Mutex
+	synced []string // names relative to root
+}
+
+func (r *syncRecorder) Create(name string) (File, error) {
+	return r.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (r *syncRecorder) Open(name string) (File, error) {
+	return r.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (r *syncRecorder) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := r.FS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &syncRecorderFile{File: f, r: r}, nil
+}
+
+// reset returns the names synced since the last call.
+func (r *syncRecorder) reset() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	synced := r.synced
+	r.synced = nil
+	return synced
+}
+
+type syncRecorderFile struct {
+	File
+	r *syncRecorder
+}
+
+func (f *syncRecorderFile) Sync() error {
+	name, err := filepath.Rel(f.r.root, f.Name())
+	if err != nil {
+		return err
+	}
+	f.r.mu.Lock()
+	f.r.synced = append(f.r.synced, filepath.ToSlash(name))
+	f.r.mu.Unlock()
+	return f.File.Sync()
+}
+
+func TestDurableFS(t *testing.T) {
+	root := TempTree(t, map[string]string{
+		"a.txt":   "a",
+		"b/c.txt": "c",
+	})
+	r := &syncRecorder{FS: SystemFS, root: root}
+	d := NewDurableFS(r)
+	path := func(name string) string {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+
+	tests := []struct {
+		name   string
+		fn     func() error
+		synced []string
+	}{
+		{"Create", func() error {
+			f, err := d.Create(path("new.txt"))
+			if err != nil {
+				return err
+			}
+			if got := r.reset(); canSyncDir && !reflect.DeepEqual(got, []string{"."}) {
+				t.Errorf("Create: synced %q before Close, want [.]", got)
+			}
+			f.Write([]byte("new"))
+			return f.Close()
+		}, []string{"new.txt"}},
+		{"OpenReadOnly", func() error {
+			f, err := d.Open(path("a.txt"))
+			if err != nil {
+				return err
+			}
+			return f.Close()
+		}, nil},
+		{"WriteFile", func() error {
+			return d.WriteFile(path("b/w.txt"), []byte("w"), 0644)
+		}, []string{"b", "b/w.txt"}},
+		{"Mkdir", func() error {
+			return d.Mkdir(path("b/dir"), 0755)
+		}, []string{"b"}},
+		{"MkdirAll", func() error {
+			return d.MkdirAll(path("b/x/y/z"), 0755)
+		}, []string{"b", "b/x", "b/x/y"}},
+		{"MkdirAllExisting", func() error {
+			return d.MkdirAll(path("b/x/y"), 0755)
+		}, nil},
+		{"Rename", func() error {
+			return d.Rename(path("a.txt"), path("b/x/a.txt"))
+		}, []string{"b/x", "."}},
+		{"RenameSameDir", func() error {
+			return d.Rename(path("b/x/a.txt"), path("b/x/a2.txt"))
+		}, []string{"b/x"}},
+		{"Link", func() error {
+			return d.Link(path("b/c.txt"), path("link.txt"))
+		}, []string{"."}},
+		{"Symlink", func() error {
+			return d.Symlink("c.txt", path("b/symlink"))
+		}, []string{"b"}},
+		{"Truncate", func() error {
+			return d.Truncate(path("b/c.txt"), 0)
+		}, []string{"b/c.txt"}},
+		{"Remove", func() error {
+			return d.Remove(path("link.txt"))
+		}, []string{"."}},
+		{"RemoveAll", func() error {
+			return d.RemoveAll(path("b/x"))
+		}, []string{"b"}},
+		{"RemoveAllMissing", func() error {
+			return d.RemoveAll(path("missing/x"))
+		}, nil},
+		{"Chmod", func() error {
+			return d.Chmod(path("b/c.txt"), 0600)
+		}, nil},
+	}
+	for _, test := range tests {
+		if test.name == "Symlink" && !supportsSymlinks {
+			continue
+		}
+		r.reset()
+		if err := test.fn(); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		got := r.reset()
+		if !canSyncDir {
+			// Only files are synced.
+			var files []string
+			for _, name := range test.synced {
+				if strings.HasSuffix(name, ".txt") {
+					files = append(files, name)
+				}
+			}
+			test.synced = files
+		}
+		if !reflect.DeepEqual(got, test.synced) {
+			t.Errorf("%s: synced %q, want %q", test.name, got, test.synced)
+		}
+	}
+
+	// Failed operations do not sync.
+	r.reset()
+	if err := d.Remove(path("missing")); !os.IsNotExist(err) {
+		t.Errorf("Remove(missing): error = %v, want not exist", err)
+	}
+	if got := r.reset(); len(got) != 0 {
+		t.Errorf("Remove(missing): synced %q", got)
+	}
+}
+
+func TestSyncDir(t *testing.T) {
+	dir := TempTree(t, map[string]string{"file": ""})
+	if err := SyncDir(dir); err != nil {
+		t.Fatalf("SyncDir: %v", err)
+	}
+	if err := SyncDir(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("SyncDir(missing): error = %v, want not exist", err)
+	}
+}
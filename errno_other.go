@@ -0,0 +1,13 @@
+//go:build !plan9
+// +build !plan9
+
+package fs
+
+import "syscall"
+
+// Errors of the simulated file systems that have no equivalent on Plan 9.
+var (
+	errBadFd    error = syscall.EBADF
+	errLoop     error = syscall.ELOOP
+	errNotEmpty error = syscall.ENOTEMPTY
+)
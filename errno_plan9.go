@@ -0,0 +1,10 @@
+package fs
+
+import "errors"
+
+// Errors of the simulated file systems that have no equivalent on Plan 9.
+var (
+	errBadFd    = errors.New("bad file descriptor")
+	errLoop     = errors.New("too many levels of symbolic links")
+	errNotEmpty = errors.New("directory not empty")
+)
@@ -0,0 +1,7 @@
+package fs
+
+import "errors"
+
+// ErrNotSupported is returned by operations that are not supported by the
+// platform or the file system.
+var ErrNotSupported = errors.New("fs: operation not supported")
@@ -0,0 +1,443 @@
+package fs
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// A Fault describes the failures a FaultFS injects into the operations
+// matching it.
+//
+// Operations are named like the methods of FS, in lower case, except that
+// Create and OpenFile are "open" and ReadFile and WriteFile are performed
+// by opening, reading or writing, and closing the file. The methods of
+// files opened by a FaultFS are "read" (Read and ReadAt), "write" (Write
+// and WriteAt), "seek", "close", "stat", "sync", "truncate" and "readdir"
+// (ReadDir and Readdirnames).
+type Fault struct {
+	// Op is the name of the operation, empty matches all.
+	Op string
+
+	// Path is a pattern, in the syntax of filepath.Match, of the name
+	// the operation is performed on, or of either name for Link, Rename
+	// and Symlink. A pattern without path separators is matched against
+	// the last element of the name. Empty matches all.
+	Path string
+
+	// After is the number of matching calls that succeed before the
+	// fault is injected.
+	After int
+
+	// Count limits the number of times the fault is injected, 0 means
+	// no limit.
+	Count int
+
+	// Probability is the probability with which a matching call fails,
+	// 0 means always.
+	Probability float64
+
+	// Err is the error injected, for example syscall.EIO, ENOSPC, EACCES
+	// or EINTR. If nil, EIO is used, or io.ErrShortWrite for short
+	// writes.
+	Err error
+
+	// Short makes writes that fail write half of the data before
+	// returning the error.
+	Short bool
+}
+
+func (ft *Fault) match(op string, names ...string) bool {
+	if ft.Op != "" && ft.Op != op {
+		return false
+	}
+	if ft.Path == "" {
+		return true
+	}
+	pattern := filepath.FromSlash(ft.Path)
+	base := !strings.ContainsAny(pattern, `/`+string(filepath.Separator))
+	for _, name := range names {
+		if base {
+			name = filepath.Base(name)
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (ft *Fault) err() error {
+	switch {
+	case ft.Err != nil:
+		return ft.Err
+	case ft.Short:
+		return io.ErrShortWrite
+	}
+	return syscall.EIO
+}
+
+// A FaultFS is an FS that injects failures into the operations of another
+// FS, to test the error handling of code using it. The operations that
+// fail are not performed, except that files are closed and short writes
+// write part of the data.
+//
+// Injected errors are of type *PathError, or *LinkError for Link, Rename
+// and Symlink. Faults with a probability are decided by a pseudo-random
+// source, so that a FaultFS created with the same seed fails the same
+// sequence of calls.
+type FaultFS struct {
+	fsys FS
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	faults []*faultState
+}
+
+type faultState struct {
+	Fault
+	calls    int // matching calls
+	injected int // failed calls
+}
+
+// NewFaultFS returns a FaultFS performing the operations of fsys, which
+// injects the faults. If fsys is nil, SystemFS is used.
+func NewFaultFS(fsys FS, seed int64, faults ...Fault) *FaultFS {
+	if fsys == nil {
+		fsys = SystemFS
+	}
+	f := &FaultFS{fsys: fsys, rand: rand.New(rand.NewSource(seed))}
+	for _, ft := range faults {
+		f.Inject(ft)
+	}
+	return f
+}
+
+// Inject adds the fault, after the faults already injected. A call fails
+// with the first fault that triggers.
+func (f *FaultFS) Inject(ft Fault) {
+	f.mu.Lock()
+	f.faults = append(f.faults, &faultState{Fault: ft})
+	f.mu.Unlock()
+}
+
+// Reset removes all faults.
+func (f *FaultFS) Reset() {
+	f.mu.Lock()
+	f.faults = nil
+	f.mu.Unlock()
+}
+
+// Injected returns the number of calls that failed.
+func (f *FaultFS) Injected() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, ft := range f.faults {
+		n += ft.injected
+	}
+	return n
+}
+
+// fault returns the fault triggered by the call of op on names, or nil.
+func (f *FaultFS) fault(op string, names ...string) *Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ft := range f.faults {
+		if !ft.match(op, names...) {
+			continue
+		}
+		ft.calls++
+		switch {
+		case ft.calls <= ft.After:
+		case ft.Count > 0 && ft.injected >= ft.Count:
+		case ft.Probability > 0 && f.rand.Float64() >= ft.Probability:
+		default:
+			ft.injected++
+			return &ft.Fault
+		}
+	}
+	return nil
+}
+
+// check returns the error injected into the call of op on name, or nil.
+func (f *FaultFS) check(op, name string) error {
+	if ft := f.fault(op, name); ft != nil {
+		return &os.PathError{Op: op, Path: name, Err: ft.err()}
+	}
+	return nil
+}
+
+// check2 returns the error injected into the call of op on oldname and
+// newname, or nil.
+func (f *FaultFS) check2(op, oldname, newname string) error {
+	if ft := f.fault(op, oldname, newname); ft != nil {
+		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: ft.err()}
+	}
+	return nil
+}
+
+func (f *FaultFS) Chmod(name string, mode os.FileMode) error {
+	if err := f.check("chmod", name); err != nil {
+		return err
+	}
+	return f.fsys.Chmod(name, mode)
+}
+
+func (f *FaultFS) Chown(name string, uid, gid int) error {
+	if err := f.check("chown", name); err != nil {
+		return err
+	}
+	return f.fsys.Chown(name, uid, gid)
+}
+
+func (f *FaultFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	if err := f.check("chtimes", name); err != nil {
+		return err
+	}
+	return f.fsys.Chtimes(name, atime, mtime)
+}
+
+func (f *FaultFS) Lchmod(name string, mode os.FileMode) error {
+	if err := f.check("lchmod", name); err != nil {
+		return err
+	}
+	return f.fsys.Lchmod(name, mode)
+}
+
+func (f *FaultFS) Lchown(name string, uid, gid int) error {
+	if err := f.check("lchown", name); err != nil {
+		return err
+	}
+	return f.fsys.Lchown(name, uid, gid)
+}
+
+func (f *FaultFS) Link(oldname, newname string) error {
+	if err := f.check2("link", oldname, newname); err != nil {
+		return err
+	}
+	return f.fsys.Link(oldname, newname)
+}
+
+func (f *FaultFS) Mkdir(name string, perm os.FileMode) error {
+	if err := f.check("mkdir", name); err != nil {
+		return err
+	}
+	return f.fsys.Mkdir(name, perm)
+}
+
+func (f *FaultFS) MkdirAll(path string, perm os.FileMode) error {
+	if err := f.check("mkdirall", path); err != nil {
+		return err
+	}
+	return f.fsys.MkdirAll(path, perm)
+}
+
+func (f *FaultFS) ReadDir(name string) ([]os.DirEntry, error) {
+	if err := f.check("readdir", name); err != nil {
+		return nil, err
+	}
+	return f.fsys.ReadDir(name)
+}
+
+func (f *FaultFS) Readlink(name string) (string, error) {
+	if err := f.check("readlink", name); err != nil {
+		return "", err
+	}
+	return f.fsys.Readlink(name)
+}
+
+func (f *FaultFS) Remove(name string) error {
+	if err := f.check("remove", name); err != nil {
+		return err
+	}
+	return f.fsys.Remove(name)
+}
+
+func (f *FaultFS) RemoveAll(path string) error {
+	if err := f.check("removeall", path); err != nil {
+		return err
+	}
+	return f.fsys.RemoveAll(path)
+}
+
+func (f *FaultFS) Rename(oldpath, newpath string) error {
+	if err := f.check2("rename", oldpath, newpath); err != nil {
+		return err
+	}
+	return f.fsys.Rename(oldpath, newpath)
+}
+
+func (f *FaultFS) Symlink(oldname, newname string) error {
+	if err := f.check2("symlink", oldname, newname); err != nil {
+		return err
+	}
+	return f.fsys.Symlink(oldname, newname)
+}
+
+func (f *FaultFS) Truncate(name string, size int64) error {
+	if err := f.check("truncate", name); err != nil {
+		return err
+	}
+	return f.fsys.Truncate(name, size)
+}
+
+func (f *FaultFS) Create(name string) (File, error) {
+	return f.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (f *FaultFS) Open(name string) (File, error) {
+	return f.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (f *FaultFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if err := f.check("open", name); err != nil {
+		return nil, err
+	}
+	file, err := f.fsys.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &faultFile{File: file, fs: f}, nil
+}
+
+func (f *FaultFS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (f *FaultFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	file, err := f.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (f *FaultFS) Lstat(name string) (os.FileInfo, error) {
+	if err := f.check("lstat", name); err != nil {
+		return nil, err
+	}
+	return f.fsys.Lstat(name)
+}
+
+func (f *FaultFS) Stat(name string) (os.FileInfo, error) {
+	if err := f.check("stat", name); err != nil {
+		return nil, err
+	}
+	return f.fsys.Stat(name)
+}
+
+// A faultFile is a file opened by a FaultFS.
+type faultFile struct {
+	File
+	fs *FaultFS
+}
+
+func (f *faultFile) check(op string) error {
+	return f.fs.check(op, f.Name())
+}
+
+func (f *faultFile) Read(b []byte) (int, error) {
+	if err := f.check("read"); err != nil {
+		return 0, err
+	}
+	return f.File.Read(b)
+}
+
+func (f *faultFile) ReadAt(b []byte, off int64) (int, error) {
+	if err := f.check("read"); err != nil {
+		return 0, err
+	}
+	return f.File.ReadAt(b, off)
+}
+
+// write injects faults into the write of b by fn.
+func (f *faultFile) write(b []byte, fn func([]byte) (int, error)) (int, error) {
+	ft := f.fs.fault("write", f.Name())
+	if ft == nil {
+		return fn(b)
+	}
+	n := 0
+	if ft.Short && len(b) > 1 {
+		var err error
+		if n, err = fn(b[:len(b)/2]); err != nil {
+			return n, err
+		}
+	}
+	return n, &os.PathError{Op: "write", Path: f.Name(), Err: ft.err()}
+}
+
+func (f *faultFile) Write(b []byte) (int, error) {
+	return f.write(b, f.File.Write)
+}
+
+func (f *faultFile) WriteAt(b []byte, off int64) (int, error) {
+	return f.write(b, func(b []byte) (int, error) {
+		return f.File.WriteAt(b, off)
+	})
+}
+
+func (f *faultFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.check("seek"); err != nil {
+		return 0, err
+	}
+	return f.File.Seek(offset, whence)
+}
+
+// Close closes the file even if it fails.
+func (f *faultFile) Close() error {
+	err := f.check("close")
+	if cerr := f.File.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (f *faultFile) Stat() (os.FileInfo, error) {
+	if err := f.check("stat"); err != nil {
+		return nil, err
+	}
+	return f.File.Stat()
+}
+
+func (f *faultFile) Sync() error {
+	if err := f.check("sync"); err != nil {
+		return err
+	}
+	return f.File.Sync()
+}
+
+func (f *faultFile) Truncate(size int64) error {
+	if err := f.check("truncate"); err != nil {
+		return err
+	}
+	return f.File.Truncate(size)
+}
+
+func (f *faultFile) ReadDir(n int) ([]os.DirEntry, error) {
+	if err := f.check("readdir"); err != nil {
+		return nil, err
+	}
+	return f.File.ReadDir(n)
+}
+
+func (f *faultFile) Readdirnames(n int) ([]string, error) {
+	if err := f.check("readdir"); err != nil {
+		return nil, err
+	}
+	return f.File.Readdirnames(n)
+}
@@ -0,0 +1,130 @@
+package fs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+var _ FS = (*FaultFS)(nil)
+
+func TestFaultFS(t *testing.T) {
+	dir := TempTree(t, map[string]string{
+		"a.txt":   "a",
+		"b/c.log": "c",
+	})
+	path := func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+	errNoSpace := errors.New("no space left on device")
+	f := NewFaultFS(nil, 1,
+		Fault{Op: "open", Path: "*.log", Err: syscall.EACCES},
+		Fault{Op: "rename", After: 1, Count: 1, Err: errNoSpace},
+	)
+
+	if _, err := f.ReadFile(path("a.txt")); err != nil {
+		t.Errorf("ReadFile(a.txt): %v", err)
+	}
+	_, err := f.ReadFile(path("b/c.log"))
+	if !errors.Is(err, syscall.EACCES) {
+		t.Errorf("ReadFile(c.log): error = %v, want EACCES", err)
+	}
+	if pe, ok := err.(*os.PathError); !ok || pe.Op != "open" || pe.Path != path("b/c.log") {
+		t.Errorf("ReadFile(c.log): error = %#v, want *PathError", err)
+	}
+	if _, err := f.Stat(path("b/c.log")); err != nil {
+		t.Errorf("Stat(c.log): %v", err)
+	}
+
+	// The second rename fails, once.
+	for i, want := range []error{nil, errNoSpace, nil} {
+		err := f.Rename(path("a.txt"), path("a.txt"))
+		if want == nil && err != nil || !errors.Is(err, want) {
+			t.Errorf("Rename %d: error = %v, want %v", i, err, want)
+		}
+		if _, ok := err.(*os.LinkError); want != nil && !ok {
+			t.Errorf("Rename %d: error = %#v, want *LinkError", i, err)
+		}
+	}
+	if n := f.Injected(); n != 2 {
+		t.Errorf("Injected = %d, want 2", n)
+	}
+
+	f.Reset()
+	if _, err := f.ReadFile(path("b/c.log")); err != nil {
+		t.Errorf("ReadFile(c.log) after Reset: %v", err)
+	}
+
+	// The path pattern with a separator matches the whole name.
+	f.Inject(Fault{Op: "remove", Path: filepath.Join(dir, "b", "*")})
+	if err := f.Remove(path("b/c.log")); !errors.Is(err, syscall.EIO) {
+		t.Errorf("Remove(b/c.log): error = %v, want EIO", err)
+	}
+	if err := f.Remove(path("a.txt")); err != nil {
+		t.Errorf("Remove(a.txt): %v", err)
+	}
+}
+
+func TestFaultFSFile(t *testing.T) {
+	dir := TempTree(t, nil)
+	name := filepath.Join(dir, "file")
+	f := NewFaultFS(nil, 1,
+		Fault{Op: "write", After: 1, Short: true},
+		Fault{Op: "close"},
+	)
+	file, err := f.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := file.Write([]byte("abcd")); n != 4 || err != nil {
+		t.Errorf("Write = %d, %v; want 4, nil", n, err)
+	}
+	n, err := file.Write([]byte("efgh"))
+	if n != 2 || !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("Write = %d, %v; want 2, short write", n, err)
+	}
+	if err := file.Close(); !errors.Is(err, syscall.EIO) {
+		t.Errorf("Close: error = %v, want EIO", err)
+	}
+	// The file was closed regardless.
+	if _, err := file.Write([]byte("x")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+	data, err := ReadFile(name)
+	if err != nil || string(data) != "abcdef" {
+		t.Errorf("ReadFile = %q, %v; want %q", data, err, "abcdef")
+	}
+}
+
+func TestFaultFSProbability(t *testing.T) {
+	dir := TempTree(t, map[string]string{"file": ""})
+	name := filepath.Join(dir, "file")
+	failures := func(seed int64) []bool {
+		f := NewFaultFS(nil, seed, Fault{Op: "stat", Probability: 0.5, Err: syscall.EINTR})
+		var failed []bool
+		for i := 0; i < 64; i++ {
+			_, err := f.Stat(name)
+			if err != nil && !errors.Is(err, syscall.EINTR) {
+				t.Fatalf("Stat: %v", err)
+			}
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+	a, b := failures(42), failures(42)
+	n := 0
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("call %d: failures differ with the same seed", i)
+		}
+		if a[i] {
+			n++
+		}
+	}
+	if n == 0 || n == len(a) {
+		t.Errorf("%d of %d calls failed with probability 0.5", n, len(a))
+	}
+}
@@ -0,0 +1,67 @@
+package fs
+
+import (
+	"os"
+	"strconv"
+)
+
+// A FileID identifies a file on the system: two paths or open files with
+// the same FileID refer to the same file. FileIDs are comparable and can
+// be used as map keys, but are only stable while the file exists.
+type FileID struct {
+	Dev uint64 // device, or volume serial number on Windows
+	Ino uint64 // inode, or file index on Windows
+}
+
+func (id FileID) String() string {
+	return strconv.FormatUint(id.Dev, 10) + ":" + strconv.FormatUint(id.Ino, 10)
+}
+
+// FileIDOf returns the FileID of a FileInfo returned by Stat, Lstat or the
+// Stat method of a File. It reports false if the FileInfo does not include
+// the identity of the file, which is always the case on Windows, where
+// the FileID of a file must be read with GetFileID or LgetFileID.
+func FileIDOf(fi os.FileInfo) (FileID, bool) {
+	return fileID(fi)
+}
+
+// GetFileID returns the FileID of the named file. If the file is a
+// symbolic link, it returns the FileID of the link's target.
+// If there is an error, it will be of type *PathError.
+func GetFileID(name string) (FileID, error) {
+	return getFileID(name, true)
+}
+
+// LgetFileID is like GetFileID, but if the file is a symbolic link it
+// returns the FileID of the link itself.
+func LgetFileID(name string) (FileID, error) {
+	return getFileID(name, false)
+}
+
+// FgetFileID returns the FileID of f.
+// If there is an error, it will be of type *PathError.
+func FgetFileID(f *os.File) (FileID, error) {
+	return fgetFileID(f)
+}
+
+// SameFile reports whether fi1 and fi2 describe the same file. Like
+// os.SameFile, it only works for FileInfos returned by this package and the
+// os package.
+func SameFile(fi1, fi2 os.FileInfo) bool {
+	return os.SameFile(fi1, fi2)
+}
+
+// SamePath reports whether the named files, after following symbolic
+// links, are the same file.
+// If there is an error, it will be of type *PathError.
+func SamePath(name1, name2 string) (bool, error) {
+	id1, err := GetFileID(name1)
+	if err != nil {
+		return false, err
+	}
+	id2, err := GetFileID(name2)
+	if err != nil {
+		return false, err
+	}
+	return id1 == id2, nil
+}
@@ -0,0 +1,32 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+func fileID(fi os.FileInfo) (FileID, bool) {
+	d, ok := fi.Sys().(*syscall.Dir)
+	if !ok {
+		return FileID{}, false
+	}
+	return FileID{Dev: uint64(d.Type)<<32 | uint64(d.Dev), Ino: d.Qid.Path}, true
+}
+
+func getFileID(name string, follow bool) (FileID, error) {
+	fi, err := Stat(name)
+	if err != nil {
+		return FileID{}, err
+	}
+	id, _ := fileID(fi)
+	return id, nil
+}
+
+func fgetFileID(f *os.File) (FileID, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return FileID{}, err
+	}
+	id, _ := fileID(fi)
+	return id, nil
+}
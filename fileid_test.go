@@ -0,0 +1,131 @@
+package fs
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileID(t *testing.T) {
+	dir := newDir("TestFileID", t)
+	defer RemoveAll(dir)
+	name := filepath.Join(dir, "file")
+	other := filepath.Join(dir, "other")
+	hard := filepath.Join(dir, "hard")
+	for _, s := range []string{name, other} {
+		f, err := Create(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	if err := Link(name, hard); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := GetFileID(name)
+	if err != nil {
+		t.Fatalf("GetFileID: %v", err)
+	}
+	if id2, err := GetFileID(hard); err != nil || id2 != id {
+		t.Errorf("GetFileID of hard link = %v, %v; want %v", id2, err, id)
+	}
+	if id2, err := GetFileID(other); err != nil || id2 == id {
+		t.Errorf("GetFileID of other file = %v, %v; want different from %v", id2, err, id)
+	}
+
+	f, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if id2, err := FgetFileID(f); err != nil || id2 != id {
+		t.Errorf("FgetFileID = %v, %v; want %v", id2, err, id)
+	}
+
+	// FileIDs are usable as map keys.
+	seen := map[FileID]string{id: name}
+	if id2, _ := GetFileID(hard); seen[id2] != name {
+		t.Errorf("map lookup of %v failed", id2)
+	}
+
+	fi, err := Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id2, ok := FileIDOf(fi); ok && id2 != id {
+		t.Errorf("FileIDOf = %v; want %v", id2, id)
+	}
+	hfi, err := Stat(hard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SameFile(fi, hfi) {
+		t.Error("SameFile of hard links = false")
+	}
+
+	if _, err := GetFileID(filepath.Join(dir, "missing")); err == nil {
+		t.Error("GetFileID of missing file: expected error")
+	}
+}
+
+func TestFileIDSymlink(t *testing.T) {
+	if !supportsSymlinks {
+		t.Skip("symlinks not supported")
+	}
+	dir := newDir("TestFileIDSymlink", t)
+	defer RemoveAll(dir)
+	name := filepath.Join(dir, "file")
+	link := filepath.Join(dir, "link")
+	f, err := Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := Symlink(name, link); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := GetFileID(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id2, err := GetFileID(link); err != nil || id2 != id {
+		t.Errorf("GetFileID of symlink = %v, %v; want %v", id2, err, id)
+	}
+	if id2, err := LgetFileID(link); err != nil || id2 == id {
+		t.Errorf("LgetFileID of symlink = %v, %v; want different from %v", id2, err, id)
+	}
+	if ok, err := SamePath(link, name); err != nil || !ok {
+		t.Errorf("SamePath(link, target) = %v, %v; want true", ok, err)
+	}
+}
+
+func TestSamePathLong(t *testing.T) {
+	dir := newDir("TestSamePathLong", t)
+	defer RemoveAll(dir)
+
+	// Longer than MAX_PATH on Windows.
+	long := dir
+	for len(long) < 300 {
+		long = filepath.Join(long, strings.Repeat("d", 20))
+	}
+	if err := MkdirAll(long, 0755); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(long, "file")
+	f, err := Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := Link(name, name+".link"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := SamePath(name, name+".link"); err != nil || !ok {
+		t.Errorf("SamePath = %v, %v; want true", ok, err)
+	}
+	if ok, err := SamePath(name, long); err != nil || ok {
+		t.Errorf("SamePath(file, dir) = %v, %v; want false", ok, err)
+	}
+}
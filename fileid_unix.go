@@ -0,0 +1,39 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+func fileID(fi os.FileInfo) (FileID, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return FileID{}, false
+	}
+	return FileID{Dev: uint64(st.Dev), Ino: uint64(st.Ino)}, true
+}
+
+func getFileID(name string, follow bool) (FileID, error) {
+	stat := Stat
+	if !follow {
+		stat = Lstat
+	}
+	fi, err := stat(name)
+	if err != nil {
+		return FileID{}, err
+	}
+	id, _ := fileID(fi)
+	return id, nil
+}
+
+func fgetFileID(f *os.File) (FileID, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return FileID{}, err
+	}
+	id, _ := fileID(fi)
+	return id, nil
+}
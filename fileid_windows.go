@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// The FileInfos of the os package do not expose the volume serial number
+// and file index, they are only loaded by os.SameFile.
+func fileID(fi os.FileInfo) (FileID, bool) {
+	return FileID{}, false
+}
+
+func handleFileID(h syscall.Handle) (FileID, error) {
+	var d syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &d); err != nil {
+		return FileID{}, err
+	}
+	return FileID{
+		Dev: uint64(d.VolumeSerialNumber),
+		Ino: uint64(d.FileIndexHigh)<<32 | uint64(d.FileIndexLow),
+	}, nil
+}
+
+func getFileID(name string, follow bool) (FileID, error) {
+	op := "getfileid"
+	flags := uint32(syscall.FILE_FLAG_BACKUP_SEMANTICS)
+	if !follow {
+		op = "lgetfileid"
+		flags |= syscall.FILE_FLAG_OPEN_REPARSE_POINT
+	}
+	p, err := winPath(name)
+	if err != nil {
+		return FileID{}, newPathError(op, name, err)
+	}
+	path, err := syscall.UTF16PtrFromString(p)
+	if err != nil {
+		return FileID{}, newPathError(op, name, err)
+	}
+	h, err := syscall.CreateFile(path, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, flags, 0)
+	if err != nil {
+		return FileID{}, newPathError(op, name, err)
+	}
+	defer syscall.CloseHandle(h)
+	id, err := handleFileID(h)
+	if err != nil {
+		return FileID{}, newPathError(op, name, err)
+	}
+	return id, nil
+}
+
+func fgetFileID(f *os.File) (FileID, error) {
+	var id FileID
+	err := controlFd(f, func(fd uintptr) error {
+		var err error
+		id, err = handleFileID(syscall.Handle(fd))
+		return err
+	})
+	if err != nil {
+		return FileID{}, newPathError("fgetfileid", f.Name(), err)
+	}
+	return id, nil
+}
@@ -0,0 +1,114 @@
+package fs
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// A File is an open file of an FS. It is implemented by *os.File.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.WriterAt
+	io.Seeker
+	io.Closer
+
+	// Name returns the name of the file as presented to Open.
+	Name() string
+
+	// Stat returns the FileInfo describing the file.
+	Stat() (os.FileInfo, error)
+
+	// Sync commits the current contents of the file to stable storage.
+	Sync() error
+
+	// Truncate changes the size of the file.
+	Truncate(size int64) error
+
+	// ReadDir reads the contents of the directory, see os.File.ReadDir.
+	ReadDir(n int) ([]os.DirEntry, error)
+
+	// Readdirnames reads the names of the directory entries, see
+	// os.File.Readdirnames.
+	Readdirnames(n int) ([]string, error)
+}
+
+// An FS provides the file system operations of this package. It allows
+// code to run against file systems other than the one of the process,
+// such as a WorkDir, or wrappers simulating failures.
+//
+// The methods behave like the functions of this package of the same name.
+type FS interface {
+	Chmod(name string, mode os.FileMode) error
+	Chown(name string, uid, gid int) error
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+	Lchmod(name string, mode os.FileMode) error
+	Lchown(name string, uid, gid int) error
+	Link(oldname, newname string) error
+	Mkdir(name string, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	ReadDir(name string) ([]os.DirEntry, error)
+	Readlink(name string) (string, error)
+	Remove(name string) error
+	RemoveAll(path string) error
+	Rename(oldpath, newpath string) error
+	Symlink(oldname, newname string) error
+	Truncate(name string, size int64) error
+
+	Create(name string) (File, error)
+	Open(name string) (File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+
+	Lstat(name string) (os.FileInfo, error)
+	Stat(name string) (os.FileInfo, error)
+}
+
+// SystemFS is the FS implemented by the functions of this package, it
+// operates on the file system of the process.
+var SystemFS FS = systemFS{}
+
+type systemFS struct{}
+
+func (systemFS) Chmod(name string, mode os.FileMode) error    { return Chmod(name, mode) }
+func (systemFS) Chown(name string, uid, gid int) error        { return Chown(name, uid, gid) }
+func (systemFS) Lchmod(name string, mode os.FileMode) error   { return Lchmod(name, mode) }
+func (systemFS) Lchown(name string, uid, gid int) error       { return Lchown(name, uid, gid) }
+func (systemFS) Link(oldname, newname string) error           { return Link(oldname, newname) }
+func (systemFS) Mkdir(name string, perm os.FileMode) error    { return Mkdir(name, perm) }
+func (systemFS) MkdirAll(path string, perm os.FileMode) error { return MkdirAll(path, perm) }
+func (systemFS) ReadDir(name string) ([]os.DirEntry, error)   { return ReadDir(name) }
+func (systemFS) Readlink(name string) (string, error)         { return Readlink(name) }
+func (systemFS) Remove(name string) error                     { return Remove(name) }
+func (systemFS) RemoveAll(path string) error                  { return RemoveAll(path) }
+func (systemFS) Rename(oldpath, newpath string) error         { return Rename(oldpath, newpath) }
+func (systemFS) Symlink(oldname, newname string) error        { return Symlink(oldname, newname) }
+func (systemFS) Truncate(name string, size int64) error       { return Truncate(name, size) }
+func (systemFS) Create(name string) (File, error)             { return toFile(Create(name)) }
+func (systemFS) Open(name string) (File, error)               { return toFile(Open(name)) }
+func (systemFS) ReadFile(name string) ([]byte, error)         { return ReadFile(name) }
+func (systemFS) Lstat(name string) (os.FileInfo, error)       { return Lstat(name) }
+func (systemFS) Stat(name string) (os.FileInfo, error)        { return Stat(name) }
+
+func (systemFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return Chtimes(name, atime, mtime)
+}
+
+func (systemFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return toFile(OpenFile(name, flag, perm))
+}
+
+func (systemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return WriteFile(name, data, perm)
+}
+
+// toFile returns f as a File, the File is nil if there is an error.
+func toFile(f *os.File, err error) (File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -44,6 +44,17 @@ func Chdir(dir string) error {
 	return chdir(dir)
 }
 
+// Getwd returns a rooted path name corresponding to the
+// current directory. If the current directory can be
+// reached via multiple paths (due to symbolic links),
+// Getwd may return any one of them.
+//
+// Unlike os.Getwd, on Linux the path may be longer than PATH_MAX and on
+// Windows the \\?\ prefix of long paths is removed.
+func Getwd() (dir string, err error) {
+	return getwd()
+}
+
 // Chmod changes the mode of the named file to mode.
 // If the file is a symbolic link, it changes the mode of the link's target.
 // If there is an error, it will be of type *PathError.
@@ -51,6 +62,15 @@ func Chmod(name string, mode os.FileMode) error {
 	return chmod(name, mode)
 }
 
+// Lchmod changes the mode of the named file to mode.
+// If the file is a symbolic link, it changes the mode of the link itself.
+// Changing the mode of a symbolic link is not supported by most platforms,
+// in which case the error wraps ErrNotSupported.
+// If there is an error, it will be of type *PathError.
+func Lchmod(name string, mode os.FileMode) error {
+	return lchmod(name, mode)
+}
+
 // Chown changes the numeric uid and gid of the named file.
 // If the file is a symbolic link, it changes the uid and gid of the link's target.
 // If there is an error, it will be of type *PathError.
@@ -98,6 +118,15 @@ func MkdirAll(path string, perm os.FileMode) error {
 	return mkdirall(path, perm)
 }
 
+// ReadDir reads the named directory,
+// returning all its directory entries sorted by filename.
+// If an error occurs reading the directory,
+// ReadDir returns the entries it was able to read before the error,
+// along with the error.
+func ReadDir(name string) ([]os.DirEntry, error) {
+	return readdir(name)
+}
+
 // Readlink returns the destination of the named symbolic link.
 // If there is an error, it will be of type *PathError.
 func Readlink(name string) (string, error) {
@@ -130,6 +159,13 @@ func Symlink(oldname, newname string) error {
 	return symlink(oldname, newname)
 }
 
+// Truncate changes the size of the named file.
+// If the file is a symbolic link, it changes the size of the link's target.
+// If there is an error, it will be of type *PathError.
+func Truncate(name string, size int64) error {
+	return truncate(name, size)
+}
+
 // File
 
 // Create creates the named file with mode 0666 (before umask), truncating
@@ -163,6 +199,44 @@ func OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
 	return openfile(name, flag, perm)
 }
 
+// ReadFile reads the named file and returns the contents.
+// A successful call returns err == nil, not err == EOF.
+// Because ReadFile reads the whole file, it does not treat an EOF from Read
+// as an error to be reported.
+func ReadFile(name string) ([]byte, error) {
+	return readfile(name)
+}
+
+// WriteFile writes data to the named file, creating it if necessary.
+// If the file does not exist, WriteFile creates it with permissions perm (before umask);
+// otherwise WriteFile truncates it before writing, without changing permissions.
+func WriteFile(name string, data []byte, perm os.FileMode) error {
+	return writefile(name, data, perm)
+}
+
+// CreateUnnamed creates a new file with mode 0600 in the directory dir,
+// which is not visible in the file system until it is published with
+// Publish. To discard a file that is not published, close it and remove
+// f.Name(), ignoring not-exist errors.
+//
+// On Linux the file is created with O_TMPFILE: it has no name until it is
+// published and is removed when closed, f.Name() is its path in
+// /proc/self/fd. On other platforms, and on file systems that do not
+// support O_TMPFILE, a hidden temporary file is created in dir instead.
+// If there is an error, it will be of type *PathError.
+func CreateUnnamed(dir string) (*os.File, error) {
+	return createUnnamed(dir)
+}
+
+// Publish makes a file created by CreateUnnamed visible as name, which
+// must be in the same file system as the directory the file was created
+// in. If name already exists it is atomically replaced. A file can only be
+// published once.
+// If there is an error, it will be of type *PathError.
+func Publish(f *os.File, name string) error {
+	return publish(f, name)
+}
+
 // FileInfo
 
 // Lstat returns a FileInfo describing the named file.
@@ -35,6 +35,7 @@ package fs
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -166,7 +167,7 @@ func localTmp() string {
 }
 
 func newFile(testName string, t *testing.T) (f *os.File) {
-	f, err := ioutil.TempFile(localTmp(), "_Go_"+testName)
+	f, err := CreateTemp(localTmp(), "_Go_"+testName)
 	if err != nil {
 		t.Fatalf("TempFile %s: %s", testName, err)
 	}
@@ -174,7 +175,7 @@ func newFile(testName string, t *testing.T) (f *os.File) {
 }
 
 func newDir(testName string, t *testing.T) (name string) {
-	name, err := ioutil.TempDir(localTmp(), "_Go_"+testName)
+	name, err := MkdirTemp(localTmp(), "_Go_"+testName)
 	if err != nil {
 		t.Fatalf("TempDir %s: %s", testName, err)
 	}
@@ -394,7 +395,7 @@ func TestReaddirNValues(t *testing.T) {
 	if testing.Short() {
 		t.Skip("test.short; skipping")
 	}
-	dir, err := ioutil.TempDir("", "")
+	dir, err := MkdirTemp("", "")
 	if err != nil {
 		t.Fatalf("TempDir: %v", err)
 	}
@@ -463,7 +464,7 @@ func TestReaddirNValues(t *testing.T) {
 
 // Readdir on a regular file should fail.
 func TestReaddirOfFile(t *testing.T) {
-	f, err := ioutil.TempFile("", "_Go_ReaddirOfFile")
+	f, err := CreateTemp("", "_Go_ReaddirOfFile")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -539,7 +540,7 @@ func chtmpdir(t *testing.T) func() {
 	if err != nil {
 		t.Fatalf("chtmpdir: %v", err)
 	}
-	d, err := ioutil.TempDir("", "test")
+	d, err := MkdirTemp("", "test")
 	if err != nil {
 		t.Fatalf("chtmpdir: %v", err)
 	}
@@ -845,11 +846,11 @@ func TestTruncate(t *testing.T) {
 	checkSize(t, f, 0)
 	f.Write([]byte("hello, world\n"))
 	checkSize(t, f, 13)
-	os.Truncate(f.Name(), 10)
+	Truncate(f.Name(), 10)
 	checkSize(t, f, 10)
-	os.Truncate(f.Name(), 1024)
+	Truncate(f.Name(), 1024)
 	checkSize(t, f, 1024)
-	os.Truncate(f.Name(), 0)
+	Truncate(f.Name(), 0)
 	checkSize(t, f, 0)
 	_, err := f.Write([]byte("surprise!"))
 	if err == nil {
@@ -857,6 +858,100 @@ func TestTruncate(t *testing.T) {
 	}
 }
 
+func TestReadWriteFile(t *testing.T) {
+	dir := newDir("TestReadWriteFile", t)
+	defer RemoveAll(dir)
+	name := filepath.Join(dir, "file")
+
+	data := []byte("hello, world\n")
+	if err := WriteFile(name, data, 0644); err != nil {
+		t.Fatalf("WriteFile %s: %v", name, err)
+	}
+	got, err := ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile %s: %v", name, err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("ReadFile %s = %q, want %q", name, got, data)
+	}
+	// WriteFile truncates existing files.
+	if err := WriteFile(name, data[:5], 0600); err != nil {
+		t.Fatalf("WriteFile %s: %v", name, err)
+	}
+	if got, _ := ReadFile(name); string(got) != "hello" {
+		t.Errorf("ReadFile %s = %q, want %q", name, got, "hello")
+	}
+	if runtime.GOOS != "windows" {
+		checkMode(t, name, 0644)
+	}
+
+	if _, err := ReadFile(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("ReadFile of missing file: error = %v, want not exist", err)
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir := newDir("TestReadDir", t)
+	defer RemoveAll(dir)
+	makeTree(t, dir, "c", "a", "b/", "b/d")
+
+	entries, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir %s: %v", dir, err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("ReadDir %s = %q, want [a b c]", dir, names)
+	}
+	if !entries[1].IsDir() || entries[0].IsDir() {
+		t.Errorf("ReadDir %s: wrong entry types", dir)
+	}
+	if _, err := ReadDir(filepath.Join(dir, "a")); err == nil {
+		t.Errorf("ReadDir of a file: expected error")
+	}
+}
+
+func TestLchmod(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skipf("skipping on %s", runtime.GOOS)
+	}
+	f := newFile("TestLchmod", t)
+	defer Remove(f.Name())
+	defer f.Close()
+
+	if err := Lchmod(f.Name(), 0456); err != nil {
+		t.Fatalf("Lchmod %s: %v", f.Name(), err)
+	}
+	checkMode(t, f.Name(), 0456)
+
+	if !supportsSymlinks {
+		return
+	}
+	link := f.Name() + ".link"
+	if err := Symlink(f.Name(), link); err != nil {
+		t.Fatal(err)
+	}
+	defer Remove(link)
+	err := Lchmod(link, 0600)
+	if err != nil && !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Lchmod %s: %v", link, err)
+	}
+	if err == nil {
+		fi, err := Lstat(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode()&0777 != 0600 {
+			t.Errorf("Lstat %s after Lchmod: mode %#o want 0600", link, fi.Mode())
+		}
+	}
+	// The target must not have been changed.
+	checkMode(t, f.Name(), 0456)
+}
+
 func TestChdirAndGetwd(t *testing.T) {
 	// TODO(brainman): file.Chdir() is not implemented on windows.
 	if runtime.GOOS == "windows" {
@@ -878,11 +973,11 @@ func TestChdirAndGetwd(t *testing.T) {
 	case "darwin":
 		switch runtime.GOARCH {
 		case "arm", "arm64":
-			d1, err := ioutil.TempDir("", "d1")
+			d1, err := MkdirTemp("", "d1")
 			if err != nil {
 				t.Fatalf("TempDir: %v", err)
 			}
-			d2, err := ioutil.TempDir("", "d2")
+			d2, err := MkdirTemp("", "d2")
 			if err != nil {
 				t.Fatalf("TempDir: %v", err)
 			}
@@ -906,7 +1001,7 @@ func TestChdirAndGetwd(t *testing.T) {
 			if d == "/tmp" {
 				os.Setenv("PWD", "/tmp")
 			}
-			pwd, err1 := os.Getwd()
+			pwd, err1 := Getwd()
 			os.Setenv("PWD", oldwd)
 			err2 := fd.Chdir()
 			if err2 != nil {
@@ -951,7 +1046,7 @@ func TestProgWideChdir(t *testing.T) {
 				runtime.LockOSThread()
 			}
 			<-c
-			pwd, err := os.Getwd()
+			pwd, err := Getwd()
 			if err != nil {
 				t.Errorf("Getwd on goroutine %d: %v", i, err)
 				return
@@ -959,11 +1054,11 @@ func TestProgWideChdir(t *testing.T) {
 			cpwd <- pwd
 		}(i)
 	}
-	oldwd, err := os.Getwd()
+	oldwd, err := Getwd()
 	if err != nil {
 		t.Fatalf("Getwd: %v", err)
 	}
-	d, err := ioutil.TempDir("", "test")
+	d, err := MkdirTemp("", "test")
 	if err != nil {
 		t.Fatalf("TempDir: %v", err)
 	}
@@ -978,7 +1073,7 @@ func TestProgWideChdir(t *testing.T) {
 	}
 	// OS X sets TMPDIR to a symbolic link.
 	// So we resolve our working directory again before the test.
-	d, err = os.Getwd()
+	d, err = Getwd()
 	if err != nil {
 		t.Fatalf("Getwd: %v", err)
 	}
@@ -1223,7 +1318,7 @@ func TestAppend(t *testing.T) {
 
 func TestStatDirWithTrailingSlash(t *testing.T) {
 	// Create new temporary directory and arrange to clean it up.
-	path, err := ioutil.TempDir("", "/_TestStatDirWithSlash_")
+	path, err := MkdirTemp("", "_TestStatDirWithSlash_")
 	if err != nil {
 		t.Fatalf("TempDir: %s", err)
 	}
@@ -1336,7 +1431,7 @@ func TestLargeWriteToConsole(t *testing.T) {
 func TestStatDirModeExec(t *testing.T) {
 	const mode = 0111
 
-	path, err := ioutil.TempDir("", "go-build")
+	path, err := MkdirTemp("", "go-build")
 	if err != nil {
 		t.Fatalf("Failed to create temp directory: %v", err)
 	}
@@ -1430,7 +1525,7 @@ func TestRemoveAllRace(t *testing.T) {
 
 	n := runtime.GOMAXPROCS(16)
 	defer runtime.GOMAXPROCS(n)
-	root, err := ioutil.TempDir("", "issue")
+	root, err := MkdirTemp("", "issue")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -8,6 +8,12 @@ import (
 	"time"
 )
 
+// canSyncDir reports whether directories can be synced.
+const canSyncDir = true
+
+// canRemoveOpen reports whether files can be removed while they are open.
+const canRemoveOpen = true
+
 func chdir(dir string) error {
 	return os.Chdir(dir)
 }
@@ -60,6 +66,14 @@ func symlink(oldname, newname string) error {
 	return os.Symlink(oldname, newname)
 }
 
+func truncate(name string, size int64) error {
+	return os.Truncate(name, size)
+}
+
+func readdir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
 func create(name string) (*os.File, error) {
 	return os.Create(name)
 }
@@ -76,6 +90,14 @@ func openfile(name string, flag int, perm os.FileMode) (*os.File, error) {
 	return os.OpenFile(name, flag, perm)
 }
 
+func readfile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func writefile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
 func lstat(name string) (os.FileInfo, error) {
 	return os.Lstat(name)
 }
@@ -48,6 +48,14 @@ func winPath(path string) (string, error) {
 	return path, nil
 }
 
+// canSyncDir reports whether directories can be synced, which requires
+// them to be opened for writing on Windows.
+const canSyncDir = false
+
+// canRemoveOpen reports whether files can be removed while they are open,
+// which requires them to be opened with FILE_SHARE_DELETE on Windows.
+const canRemoveOpen = false
+
 func newPathError(op, path string, err error) error {
 	return &os.PathError{
 		Op:   "fs: " + op,
@@ -73,6 +81,20 @@ func chdir(dir string) error {
 	return os.Chdir(p)
 }
 
+func getwd() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasPrefix(dir, `\\?\UNC\`):
+		return `\\` + dir[len(`\\?\UNC\`):], nil
+	case strings.HasPrefix(dir, `\\?\`):
+		return dir[len(`\\?\`):], nil
+	}
+	return dir, nil
+}
+
 func chmod(name string, mode os.FileMode) error {
 	p, err := winPath(name)
 	if err != nil {
@@ -244,6 +266,22 @@ func symlink(oldname, newname string) error {
 	return os.Symlink(op, np)
 }
 
+func truncate(name string, size int64) error {
+	p, err := winPath(name)
+	if err != nil {
+		return newPathError("truncate", name, err)
+	}
+	return os.Truncate(p, size)
+}
+
+func readdir(name string) ([]os.DirEntry, error) {
+	p, err := winPath(name)
+	if err != nil {
+		return nil, newPathError("readdir", name, err)
+	}
+	return os.ReadDir(p)
+}
+
 func create(name string) (*os.File, error) {
 	p, err := winPath(name)
 	if err != nil {
@@ -276,6 +314,22 @@ func openfile(name string, flag int, perm os.FileMode) (*os.File, error) {
 	return os.OpenFile(p, flag, perm)
 }
 
+func readfile(name string) ([]byte, error) {
+	p, err := winPath(name)
+	if err != nil {
+		return nil, newPathError("readfile", name, err)
+	}
+	return os.ReadFile(p)
+}
+
+func writefile(name string, data []byte, perm os.FileMode) error {
+	p, err := winPath(name)
+	if err != nil {
+		return newPathError("writefile", name, err)
+	}
+	return os.WriteFile(p, data, perm)
+}
+
 func lstat(name string) (os.FileInfo, error) {
 	p, err := winPath(name)
 	if err != nil {
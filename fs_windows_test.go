@@ -7,7 +7,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -66,11 +65,7 @@ func longPathName() string {
 }
 
 func tempDir(t *testing.T) string {
-	path, err := ioutil.TempDir("", "fs-test")
-	if err != nil {
-		t.Fatal(err)
-	}
-	return path
+	return TempTree(t, nil)
 }
 
 func TestMkdir(t *testing.T) {
@@ -109,6 +104,62 @@ func TestMkdirAll(t *testing.T) {
 	}
 }
 
+func TestTempLong(t *testing.T) {
+	dir := filepath.Join(tempDir(t), longPathName())
+	if err := MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := CreateTemp(dir, "file-*.txt")
+	if err != nil {
+		t.Fatalf("CreateTemp: %s", err)
+	}
+	defer f.Close()
+	if _, err := Stat(f.Name()); err != nil {
+		t.Fatalf("CreateTemp: Stat failed %s", err)
+	}
+
+	name, err := MkdirTemp(dir, "dir-*")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %s", err)
+	}
+	if fi, err := Stat(name); err != nil || !fi.IsDir() {
+		t.Fatalf("MkdirTemp: Stat failed %v", err)
+	}
+}
+
+func TestLongReadWriteFile(t *testing.T) {
+	dir := filepath.Join(tempDir(t), longPathName())
+	if err := MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "file")
+
+	if err := WriteFile(name, []byte("hello, world"), 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	if err := Truncate(name, 5); err != nil {
+		t.Fatalf("Truncate: %s", err)
+	}
+	data, err := ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile: %s", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("ReadFile = %q, want %q", data, "hello")
+	}
+	entries, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "file" {
+		t.Errorf("ReadDir = %v, want [file]", entries)
+	}
+	if _, err := DirFS(dir).Open("file"); err != nil {
+		t.Errorf("DirFS: %s", err)
+	}
+}
+
 func TestRemoveAll(t *testing.T) {
 	temp := tempDir(t)
 	path := filepath.Join(temp, longPathName())
@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+func getwd() (string, error) {
+	dir, err := os.Getwd()
+	if err == nil || !errors.Is(err, syscall.ENAMETOOLONG) {
+		return dir, err
+	}
+	if dir, ok := getwdProc(); ok {
+		return dir, nil
+	}
+	return "", err
+}
+
+// getwdProc returns the current directory by looking up the name of each
+// directory in its parent, starting at ".". The directories are accessed
+// via /proc/self/fd so that the paths used stay short.
+func getwdProc() (string, bool) {
+	root, err := os.Stat("/")
+	if err != nil {
+		return "", false
+	}
+	cur, err := os.Open(".")
+	if err != nil {
+		return "", false
+	}
+	defer func() { cur.Close() }()
+	fi, err := cur.Stat()
+	if err != nil {
+		return "", false
+	}
+
+	dir := ""
+	for !os.SameFile(fi, root) {
+		parent, err := os.Open(procFdPath(cur) + "/..")
+		if err != nil {
+			return "", false
+		}
+		cur.Close()
+		cur = parent
+		names, err := parent.Readdirnames(-1)
+		if err != nil {
+			return "", false
+		}
+		found := false
+		for _, name := range names {
+			d, err := os.Lstat(procFdPath(parent) + "/" + name)
+			if err == nil && os.SameFile(d, fi) {
+				dir = "/" + name + dir
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", false
+		}
+		if fi, err = parent.Stat(); err != nil {
+			return "", false
+		}
+	}
+	if dir == "" {
+		dir = "/"
+	}
+	return dir, true
+}
+
+// procFdPath returns the path of f in /proc/self/fd.
+func procFdPath(f *os.File) string {
+	return "/proc/self/fd/" + strconv.Itoa(int(f.Fd()))
+}
@@ -0,0 +1,56 @@
+package fs
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGetwdLong(t *testing.T) {
+	root := TempTree(t, nil)
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := Chdir(oldwd); err != nil {
+			t.Fatalf("Chdir: %v", err)
+		}
+	}()
+	if err := Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	want, err := Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a directory whose path is longer than PATH_MAX, one component
+	// at a time.
+	elem := strings.Repeat("x", 200)
+	for len(want) <= 2*4096 {
+		if err := Mkdir(elem, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := Chdir(elem); err != nil {
+			t.Fatal(err)
+		}
+		want += "/" + elem
+	}
+	// os.Getwd prefers $PWD, make sure it is not used.
+	defer os.Setenv("PWD", os.Getenv("PWD"))
+	os.Unsetenv("PWD")
+
+	got, err := Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+	if got != want {
+		t.Errorf("Getwd = %q, want %q", got, want)
+	}
+	// Recent versions of os.Getwd handle long paths themselves, test the
+	// fallback directly.
+	if got, ok := getwdProc(); !ok || got != want {
+		t.Errorf("getwdProc = %q, %t, want %q", got, ok, want)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package fs
+
+import "os"
+
+func getwd() (string, error) {
+	return os.Getwd()
+}
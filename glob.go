@@ -0,0 +1,268 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ErrBadPattern indicates a pattern was malformed.
+var ErrBadPattern = filepath.ErrBadPattern
+
+// Glob returns the names of all files matching pattern or nil if there is
+// no matching file. It uses this package's Lstat and Open, so long paths
+// are handled, and the matches are sorted lexically.
+//
+// In addition to the syntax of filepath.Match, the pattern may contain:
+//
+//	**       as a complete path element, matches zero or more path
+//	         elements; symbolic links to directories are not followed
+//	{a,b,c}  matches any of the comma-separated alternatives, which may
+//	         themselves contain patterns and nested alternatives
+//
+// Like filepath.Match, escaping with a backslash is not supported on
+// Windows, where it is the path separator.
+//
+// Glob ignores file system errors such as I/O errors reading directories.
+// The only possible returned error is ErrBadPattern, when pattern is
+// malformed.
+func Glob(pattern string) ([]string, error) {
+	patterns, err := expandBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var g globber
+	for _, p := range patterns {
+		if err := g.glob(p); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(g.matches)
+	return g.matches, nil
+}
+
+type globber struct {
+	matches []string
+	seen    map[string]bool
+}
+
+func (g *globber) add(path string) {
+	if g.seen == nil {
+		g.seen = make(map[string]bool)
+	}
+	if !g.seen[path] {
+		g.seen[path] = true
+		g.matches = append(g.matches, path)
+	}
+}
+
+// glob adds the matches of pattern, which must not contain alternatives.
+func (g *globber) glob(pattern string) error {
+	vol := filepath.VolumeName(pattern)
+	rest := pattern[len(vol):]
+	dir := vol
+	if len(rest) > 0 && os.IsPathSeparator(rest[0]) {
+		dir += string(filepath.Separator)
+	}
+	var elems []string
+	for _, e := range strings.FieldsFunc(rest, isGlobSeparator) {
+		// Check the pattern is well formed even if it matches nothing.
+		if _, err := filepath.Match(e, ""); err != nil {
+			return err
+		}
+		elems = append(elems, e)
+	}
+	if len(elems) == 0 {
+		if dir != "" {
+			if _, err := Lstat(dir); err == nil {
+				g.add(dir)
+			}
+		}
+		return nil
+	}
+	return g.match(dir, elems)
+}
+
+// match adds the files below dir that match the pattern elements elems.
+func (g *globber) match(dir string, elems []string) error {
+	if len(elems) == 0 {
+		g.add(dir)
+		return nil
+	}
+	elem, rest := elems[0], elems[1:]
+
+	if elem == "**" {
+		if dir != "" || len(rest) > 0 {
+			if err := g.match(dir, rest); err != nil {
+				return err
+			}
+		}
+		d, err := Open(globDir(dir))
+		if err != nil {
+			return nil
+		}
+		infos, _ := d.Readdir(-1)
+		d.Close()
+		for _, fi := range infos {
+			path := globJoin(dir, fi.Name())
+			if fi.IsDir() {
+				if err := g.match(path, elems); err != nil {
+					return err
+				}
+			} else if err := g.match(path, rest); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !hasMeta(elem) {
+		path := globJoin(dir, unescape(elem))
+		if _, err := Lstat(path); err != nil {
+			return nil
+		}
+		return g.match(path, rest)
+	}
+
+	names, err := readDirNames(globDir(dir))
+	if err != nil {
+		return nil
+	}
+	for _, name := range names {
+		matched, err := filepath.Match(elem, name)
+		if err != nil {
+			return err
+		}
+		if matched {
+			if err := g.match(globJoin(dir, name), rest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func globDir(dir string) string {
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+func globJoin(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	if os.IsPathSeparator(dir[len(dir)-1]) {
+		return dir + name
+	}
+	return dir + string(filepath.Separator) + name
+}
+
+func isGlobSeparator(r rune) bool {
+	return r < 0x80 && os.IsPathSeparator(uint8(r))
+}
+
+// hasMeta reports whether path contains any of the magic characters
+// recognized by filepath.Match.
+func hasMeta(path string) bool {
+	magicChars := `*?[\`
+	if runtime.GOOS == "windows" {
+		magicChars = `*?[`
+	}
+	return strings.ContainsAny(path, magicChars)
+}
+
+// unescape removes the escaping backslashes from a pattern without magic
+// characters.
+func unescape(s string) string {
+	if runtime.GOOS == "windows" || !strings.Contains(s, `\`) {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+// expandBraces returns the patterns obtained by expanding the
+// alternatives of pattern, in order.
+func expandBraces(pattern string) ([]string, error) {
+	open, close, commas, err := findBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if open < 0 {
+		return []string{pattern}, nil
+	}
+	prefix, suffix := pattern[:open], pattern[close+1:]
+	var patterns []string
+	start := open + 1
+	for _, end := range append(commas, close) {
+		// The alternative may contain further alternatives, as may the
+		// remainder of the pattern, expand them recursively.
+		exp, err := expandBraces(prefix + pattern[start:end] + suffix)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, exp...)
+		start = end + 1
+	}
+	return patterns, nil
+}
+
+// findBraces returns the offsets of the first top-level "{" in pattern,
+// its matching "}" and the commas that separate its alternatives. Open is
+// -1 if the pattern has no alternatives. Braces inside of character
+// classes or escaped with a backslash are not treated as alternatives.
+func findBraces(pattern string) (open, close int, commas []int, err error) {
+	escape := runtime.GOOS != "windows"
+	open = -1
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\' && escape:
+			i++
+		case c == '[':
+			// Skip the character class, malformed classes are reported
+			// by filepath.Match.
+			j := i + 1
+			if j < len(pattern) && pattern[j] == '^' {
+				j++
+			}
+			for ; j < len(pattern) && pattern[j] != ']'; j++ {
+				if pattern[j] == '\\' && escape {
+					j++
+				}
+			}
+			if j < len(pattern) {
+				i = j
+			}
+		case c == '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		case c == '}':
+			if depth == 0 {
+				return -1, -1, nil, ErrBadPattern
+			}
+			depth--
+			if depth == 0 {
+				return open, i, commas, nil
+			}
+		}
+	}
+	if depth != 0 {
+		return -1, -1, nil, ErrBadPattern
+	}
+	return -1, -1, nil, nil
+}
@@ -0,0 +1,136 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	root := newDir("TestGlob", t)
+	defer RemoveAll(root)
+	makeTree(t, root,
+		"a/b/c.go", "a/b/c.txt", "a/b/d/e.go", "a/x.go", "a/y.txt",
+		"b/z.go", "b/{}.txt", "main.go", "README", "empty/",
+	)
+
+	tests := []struct {
+		pattern string
+		matches []string
+	}{
+		{"*.go", []string{"main.go"}},
+		{"a/*.go", []string{"a/x.go"}},
+		{"*/*.go", []string{"a/x.go", "b/z.go"}},
+		{"**/*.go", []string{"a/b/c.go", "a/b/d/e.go", "a/x.go", "b/z.go", "main.go"}},
+		{"a/**/*.go", []string{"a/b/c.go", "a/b/d/e.go", "a/x.go"}},
+		{"a/**/d", []string{"a/b/d"}},
+		{"a/b/**", []string{"a/b", "a/b/c.go", "a/b/c.txt", "a/b/d", "a/b/d/e.go"}},
+		{"**/c.*", []string{"a/b/c.go", "a/b/c.txt"}},
+		{"a/{x,y}.*", []string{"a/x.go", "a/y.txt"}},
+		{"{a,b}/*.go", []string{"a/x.go", "b/z.go"}},
+		{"{a/{b,x},b/z}.go", []string{"a/x.go", "b/z.go"}},
+		{"a/b/{c,d/e}.{go,txt}", []string{"a/b/c.go", "a/b/c.txt", "a/b/d/e.go"}},
+		{"{main,main}.go", []string{"main.go"}},
+		{"[a-b]/[xz].go", []string{"a/x.go", "b/z.go"}},
+		{"[^a-b]*", []string{"README", "empty", "main.go"}},
+		{"a/?.go", []string{"a/x.go"}},
+		{"empty", []string{"empty"}},
+		{"empty/*", nil},
+		{"nope/**", nil},
+		{"a/x.go/*", nil},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, []struct {
+			pattern string
+			matches []string
+		}{
+			{`b/\{\}.txt`, []string{"b/{}.txt"}},
+			{`b/\{*`, []string{"b/{}.txt"}},
+			{`m\ain.go`, []string{"main.go"}},
+		}...)
+	}
+
+	for _, test := range tests {
+		pattern := filepath.Join(root, filepath.FromSlash(test.pattern))
+		matches, err := Glob(pattern)
+		if err != nil {
+			t.Errorf("Glob(%q): %v", test.pattern, err)
+			continue
+		}
+		var want []string
+		for _, m := range test.matches {
+			want = append(want, filepath.Join(root, filepath.FromSlash(m)))
+		}
+		if !reflect.DeepEqual(matches, want) {
+			t.Errorf("Glob(%q):\ngot:  %q\nwant: %q", test.pattern, matches, want)
+		}
+	}
+}
+
+func TestGlobRelative(t *testing.T) {
+	root := newDir("TestGlobRelative", t)
+	defer RemoveAll(root)
+	makeTree(t, root, "a/b.go", "c.go")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer Chdir(wd)
+
+	matches, err := Glob("**/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join("a", "b.go"), "c.go"}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("Glob(%q): got: %q want: %q", "**/*.go", matches, want)
+	}
+}
+
+func TestGlobError(t *testing.T) {
+	bad := []string{
+		"[]",
+		"a/[b-",
+		"{a,b",
+		"a,b}",
+		"{a,{b}",
+		"{a,[}",
+		"**/[",
+	}
+	for _, pattern := range bad {
+		if _, err := Glob(pattern); err != ErrBadPattern {
+			t.Errorf("Glob(%q): error = %v; want: %v", pattern, err, ErrBadPattern)
+		}
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"abc", []string{"abc"}},
+		{"{a,b}", []string{"a", "b"}},
+		{"x{a,b}y", []string{"xay", "xby"}},
+		{"{a,b}{c,d}", []string{"ac", "ad", "bc", "bd"}},
+		{"{a,{b,c}}", []string{"a", "b", "c"}},
+		{"{,a}", []string{"", "a"}},
+		{"{a}", []string{"a"}},
+		{"[{,}]", []string{"[{,}]"}},
+	}
+	for _, test := range tests {
+		got, err := expandBraces(test.pattern)
+		if err != nil {
+			t.Errorf("expandBraces(%q): %v", test.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("expandBraces(%q) = %q; want: %q", test.pattern, got, test.want)
+		}
+	}
+}
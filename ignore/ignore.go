@@ -0,0 +1,190 @@
+// Package ignore implements gitignore style include/exclude filtering of
+// file trees.
+//
+// The pattern syntax and precedence rules follow those documented by
+// gitignore(5): patterns are read from ignore files found in each
+// directory of the tree, patterns in deeper directories take precedence
+// over those in their parents and within a file the last matching pattern
+// wins. A file can not be re-included if one of its parent directories is
+// excluded.
+package ignore
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charlievieth/fs"
+)
+
+// DefaultFileName is the name of the ignore files read by a Filter.
+const DefaultFileName = ".gitignore"
+
+// A Matcher is an ordered list of patterns.
+type Matcher struct {
+	patterns []*Pattern
+}
+
+// NewMatcher returns a Matcher for patterns, which are given in order of
+// increasing precedence.
+func NewMatcher(patterns []*Pattern) *Matcher {
+	return &Matcher{patterns: patterns}
+}
+
+// Parse reads the gitignore patterns from r, found in the slash separated
+// directory base.
+func Parse(r io.Reader, base string) ([]*Pattern, error) {
+	var patterns []*Pattern
+	sc := bufio.NewScanner(r)
+	for first := true; sc.Scan(); first = false {
+		line := sc.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if p, ok := ParsePattern(line, base); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns, sc.Err()
+}
+
+// Add appends patterns to m, they take precedence over the existing
+// patterns of m.
+func (m *Matcher) Add(patterns ...*Pattern) {
+	m.patterns = append(m.patterns, patterns...)
+}
+
+// Match reports whether the slash separated path is excluded by the
+// patterns of m. The parent directories of path are not considered.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	excluded, _ := m.match(path, isDir)
+	return excluded
+}
+
+// match returns whether path is excluded and whether any pattern matched.
+func (m *Matcher) match(path string, isDir bool) (excluded, matched bool) {
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		if p := m.patterns[i]; p.Match(path, isDir) {
+			return !p.Negate, true
+		}
+	}
+	return false, false
+}
+
+// A Filter excludes the files of the tree rooted at Root that are ignored
+// by the ignore files found in the tree. Ignore files are read lazily, as
+// the directories containing them are queried, using the fs package so
+// long paths are supported.
+//
+// A Filter implements fs.Filter and is safe for concurrent use.
+type Filter struct {
+	root   string
+	global *Matcher
+
+	mu      sync.Mutex
+	files   map[string][]*Pattern // ignore file patterns by directory
+	ignored map[string]bool       // cached results for directories
+}
+
+// NewFilter returns a Filter for the tree rooted at root that reads
+// patterns from files named DefaultFileName. Additional patterns, such as
+// those of a global excludes file, may be given and have the lowest
+// precedence.
+func NewFilter(root string, patterns ...*Pattern) *Filter {
+	return &Filter{
+		root:    filepath.Clean(root),
+		global:  NewMatcher(patterns),
+		files:   make(map[string][]*Pattern),
+		ignored: make(map[string]bool),
+	}
+}
+
+// Root returns the root directory of the tree filtered by f.
+func (f *Filter) Root() string { return f.root }
+
+// Include reports whether path is not ignored. Paths outside of the tree
+// rooted at f.Root are always included.
+func (f *Filter) Include(path string, info os.FileInfo) bool {
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil || rel == "." || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return true
+	}
+	return !f.Ignored(filepath.ToSlash(rel), info.IsDir())
+}
+
+// Ignored reports whether the slash separated path, relative to f.Root, is
+// ignored. A path is ignored if it or any of its parent directories is
+// excluded.
+func (f *Filter) Ignored(path string, isDir bool) bool {
+	path = strings.Trim(path, "/")
+	if path == "" || path == "." {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		if f.dirIgnored(path[:i]) {
+			return true
+		}
+	}
+	return f.excluded(path, isDir)
+}
+
+// dirIgnored reports whether the directory dir, or one of its parents, is
+// excluded. f.mu must be held.
+func (f *Filter) dirIgnored(dir string) bool {
+	if v, ok := f.ignored[dir]; ok {
+		return v
+	}
+	v := false
+	if i := strings.LastIndexByte(dir, '/'); i >= 0 {
+		v = f.dirIgnored(dir[:i])
+	}
+	if !v {
+		v = f.excluded(dir, true)
+	}
+	f.ignored[dir] = v
+	return v
+}
+
+// excluded applies the patterns of the ignore files in the parent
+// directories of path, the deepest first. f.mu must be held.
+func (f *Filter) excluded(path string, isDir bool) bool {
+	dir := path
+	for {
+		i := strings.LastIndexByte(dir, '/')
+		if i < 0 {
+			dir = ""
+		} else {
+			dir = dir[:i]
+		}
+		m := Matcher{patterns: f.load(dir)}
+		if excluded, ok := m.match(path, isDir); ok {
+			return excluded
+		}
+		if dir == "" {
+			break
+		}
+	}
+	return f.global.Match(path, isDir)
+}
+
+// load returns the patterns of the ignore file in dir. f.mu must be held.
+func (f *Filter) load(dir string) []*Pattern {
+	if p, ok := f.files[dir]; ok {
+		return p
+	}
+	name := filepath.Join(f.root, filepath.FromSlash(dir), DefaultFileName)
+	var patterns []*Pattern
+	if fh, err := fs.Open(name); err == nil {
+		// A partially read ignore file is used as is, like git does.
+		patterns, _ = Parse(fh, dir)
+		fh.Close()
+	}
+	f.files[dir] = patterns
+	return patterns
+}
@@ -0,0 +1,386 @@
+package ignore
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/charlievieth/fs"
+)
+
+type matchTest struct {
+	path    string
+	isDir   bool
+	ignored bool
+}
+
+// The test cases mirror the examples and rules of gitignore(5).
+var ignoreTests = []struct {
+	patterns string
+	tests    []matchTest
+}{
+	{
+		// Blank lines and comments match nothing.
+		"\n# hello.c\n",
+		[]matchTest{
+			{"hello.c", false, false},
+			{"# hello.c", false, false},
+		},
+	},
+	{
+		// A leading backslash escapes "#" and "!".
+		"\\#hello\n\\!world\n",
+		[]matchTest{
+			{"#hello", false, true},
+			{"!world", false, true},
+			{"world", false, false},
+		},
+	},
+	{
+		// Trailing spaces are ignored unless escaped.
+		"foo  \nbar\\ \n",
+		[]matchTest{
+			{"foo", false, true},
+			{"foo  ", false, false},
+			{"bar ", false, true},
+			{"bar", false, false},
+		},
+	},
+	{
+		// A pattern without a separator matches at any level.
+		"hello.*\n",
+		[]matchTest{
+			{"hello.c", false, true},
+			{"hello.txt", false, true},
+			{"a/hello.c", false, true},
+			{"a/b/hello.c", true, true},
+			{"hello", false, false},
+			{"ahello.c", false, false},
+		},
+	},
+	{
+		// A leading separator anchors the pattern.
+		"/hello.*\n",
+		[]matchTest{
+			{"hello.c", false, true},
+			{"a/hello.c", false, false},
+		},
+	},
+	{
+		// A separator in the middle anchors the pattern.
+		"doc/frotz/\n",
+		[]matchTest{
+			{"doc/frotz", true, true},
+			{"a/doc/frotz", true, false},
+			{"doc/frotz", false, false},
+		},
+	},
+	{
+		// A trailing separator only matches directories.
+		"frotz/\n",
+		[]matchTest{
+			{"frotz", true, true},
+			{"a/frotz", true, true},
+			{"frotz", false, false},
+		},
+	},
+	{
+		"foo/*\n",
+		[]matchTest{
+			{"foo/test.json", false, true},
+			{"foo/bar", true, true},
+			{"foo", true, false},
+			{"a/foo/test.json", false, false},
+		},
+	},
+	{
+		// The last matching pattern wins.
+		"*.log\n!important.log\n",
+		[]matchTest{
+			{"debug.log", false, true},
+			{"important.log", false, false},
+			{"logs/important.log", false, false},
+		},
+	},
+	{
+		// Exclude everything except the directory foo/bar.
+		"/*\n!/foo\n/foo/*\n!/foo/bar\n",
+		[]matchTest{
+			{"a", false, true},
+			{"foo", true, false},
+			{"foo/baz", true, true},
+			{"foo/bar", true, false},
+		},
+	},
+	{
+		// A leading "**/" matches in all directories.
+		"**/foo\n**/x/bar\n",
+		[]matchTest{
+			{"foo", false, true},
+			{"a/foo", true, true},
+			{"a/b/foo", false, true},
+			{"x/bar", false, true},
+			{"a/x/bar", false, true},
+			{"bar", false, false},
+		},
+	},
+	{
+		// A trailing "/**" matches everything inside.
+		"abc/**\n",
+		[]matchTest{
+			{"abc", true, false},
+			{"abc/x", false, true},
+			{"abc/x/y", false, true},
+			{"a/abc/x", false, false},
+		},
+	},
+	{
+		// "/**/" matches zero or more directories.
+		"a/**/b\n",
+		[]matchTest{
+			{"a/b", false, true},
+			{"a/x/b", false, true},
+			{"a/x/y/b", false, true},
+			{"a/x/y/c", false, false},
+			{"b", false, false},
+		},
+	},
+	{
+		// Other consecutive asterisks are regular asterisks.
+		"foo**bar\n",
+		[]matchTest{
+			{"foobar", false, true},
+			{"fooxbar", false, true},
+			{"foo/bar", false, false},
+		},
+	},
+	{
+		"?.txt\n[a-c].go\n[!x]y\n[]]z\n",
+		[]matchTest{
+			{"a.txt", false, true},
+			{"ab.txt", false, false},
+			{"b.go", false, true},
+			{"d.go", false, false},
+			{"ay", false, true},
+			{"xy", false, false},
+			{"]z", false, true},
+		},
+	},
+	{
+		// "*" and "?" do not match a separator.
+		"/a*b\n/a?b\n",
+		[]matchTest{
+			{"axxb", false, true},
+			{"a/b", false, false},
+			{"ax/b", false, false},
+		},
+	},
+	{
+		"\\*.txt\n",
+		[]matchTest{
+			{"*.txt", false, true},
+			{"a.txt", false, false},
+		},
+	},
+}
+
+func TestMatcher(t *testing.T) {
+	for _, test := range ignoreTests {
+		patterns, err := Parse(strings.NewReader(test.patterns), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := NewMatcher(patterns)
+		for _, x := range test.tests {
+			if got := m.Match(x.path, x.isDir); got != x.ignored {
+				t.Errorf("patterns %q: Match(%q, %t) = %t; want: %t",
+					test.patterns, x.path, x.isDir, got, x.ignored)
+			}
+		}
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var treeFiles = map[string]string{
+	".gitignore":           "*.o\nbuild/\n!build/keep\n/out\n",
+	"main.c":               "",
+	"main.o":               "",
+	"out/a":                "",
+	"build/keep":           "",
+	"build/x.c":            "",
+	"lib/.gitignore":       "!keep.o\n/gen\n",
+	"lib/keep.o":           "",
+	"lib/lib.o":            "",
+	"lib/lib.c":            "",
+	"lib/gen/x.c":          "",
+	"lib/out/x.c":          "",
+	"lib/sub/.gitignore":   "*.c\n",
+	"lib/sub/sub.c":        "",
+	"lib/sub/sub.h":        "",
+	"lib/sub/keep.o":       "",
+	"docs/gen/index.html":  "",
+	"docs/build.md":        "",
+	"docs/.gitignore":      "",
+	"vendor/a/b/c/d/x.txt": "",
+}
+
+var treeIncluded = []string{
+	".gitignore",
+	"docs",
+	"docs/.gitignore",
+	"docs/build.md",
+	"docs/gen",
+	"docs/gen/index.html",
+	"lib",
+	"lib/.gitignore",
+	"lib/keep.o",
+	"lib/lib.c",
+	"lib/out",
+	"lib/out/x.c",
+	"lib/sub",
+	"lib/sub/.gitignore",
+	"lib/sub/keep.o",
+	"lib/sub/sub.h",
+	"main.c",
+	"vendor",
+	"vendor/a",
+	"vendor/a/b",
+	"vendor/a/b/c",
+	"vendor/a/b/c/d",
+	"vendor/a/b/c/d/x.txt",
+}
+
+func tempTree(t *testing.T) string {
+	root, err := ioutil.TempDir("", "fs-ignore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fs.RemoveAll(root) })
+	writeFiles(t, root, treeFiles)
+	return root
+}
+
+func walkTree(t *testing.T, root string, filter fs.Filter) []string {
+	var names []string
+	err := fs.WalkFilter(root, filter, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFilterWalk(t *testing.T) {
+	root := tempTree(t)
+	got := walkTree(t, root, NewFilter(root))
+	if !reflect.DeepEqual(got, treeIncluded) {
+		t.Errorf("WalkFilter:\ngot:  %q\nwant: %q", got, treeIncluded)
+	}
+}
+
+func TestFilterIgnored(t *testing.T) {
+	root := tempTree(t)
+	f := NewFilter(root)
+	tests := []matchTest{
+		{"main.o", false, true},
+		{"build/keep", false, true}, // parent is excluded
+		{"lib/keep.o", false, false},
+		{"lib/gen/x.c", false, true},
+		{"lib/sub/sub.c", false, true},
+		{"does/not/exist.o", false, true},
+		{"does/not/exist.c", false, false},
+	}
+	for _, x := range tests {
+		if got := f.Ignored(x.path, x.isDir); got != x.ignored {
+			t.Errorf("Ignored(%q, %t) = %t; want: %t", x.path, x.isDir, got, x.ignored)
+		}
+	}
+}
+
+func TestFilterGlobalPatterns(t *testing.T) {
+	root := tempTree(t)
+	p, _ := ParsePattern("*.md", "")
+	f := NewFilter(root, p)
+	if !f.Ignored("docs/build.md", false) {
+		t.Error("global pattern *.md did not match docs/build.md")
+	}
+	if f.Ignored("main.c", false) {
+		t.Error("global pattern *.md matched main.c")
+	}
+}
+
+func TestFilterCopyTree(t *testing.T) {
+	root := tempTree(t)
+	dst, err := ioutil.TempDir("", "fs-ignore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.RemoveAll(dst)
+
+	if err := fs.CopyTree(dst, root, NewFilter(root)); err != nil {
+		t.Fatal(err)
+	}
+	got := walkTree(t, dst, nil)
+	if !reflect.DeepEqual(got, treeIncluded) {
+		t.Errorf("CopyTree:\ngot:  %q\nwant: %q", got, treeIncluded)
+	}
+}
+
+func TestFilterRemoveTree(t *testing.T) {
+	root := tempTree(t)
+	included := make(map[string]bool)
+	for _, name := range treeIncluded {
+		included[name] = true
+	}
+	// Ignored files survive, and so do the directories containing them.
+	keep := make(map[string]bool)
+	for _, name := range walkTree(t, root, nil) {
+		if included[name] {
+			continue
+		}
+		for ; name != "."; name = path.Dir(name) {
+			keep[name] = true
+		}
+	}
+	var want []string
+	for name := range keep {
+		want = append(want, name)
+	}
+	sort.Strings(want)
+
+	if err := fs.RemoveTree(root, NewFilter(root)); err != nil {
+		t.Fatal(err)
+	}
+	got := walkTree(t, root, nil)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RemoveTree:\ngot:  %q\nwant: %q", got, want)
+	}
+}
@@ -0,0 +1,234 @@
+package ignore
+
+import (
+	"strings"
+)
+
+// A Pattern is a single gitignore pattern.
+type Pattern struct {
+	// Base is the slash separated directory, relative to the root of the
+	// tree, of the ignore file the pattern was read from. Anchored patterns
+	// are matched relative to Base. It is empty for the root directory.
+	Base string
+
+	// Negate is set for patterns prefixed with "!", which re-include files
+	// excluded by a previous pattern.
+	Negate bool
+
+	// DirOnly is set for patterns with a trailing "/", which only match
+	// directories.
+	DirOnly bool
+
+	segments []string
+}
+
+// ParsePattern parses a single line of a gitignore file, found in the
+// directory base. It returns false if the line is blank or a comment.
+func ParsePattern(line, base string) (*Pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpace(line)
+	if line == "" || line[0] == '#' {
+		return nil, false
+	}
+	p := &Pattern{Base: strings.Trim(base, "/")}
+	if line[0] == '!' {
+		p.Negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.DirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, false
+	}
+
+	// A separator at the beginning or in the middle of the pattern anchors
+	// it to the directory of the ignore file, otherwise it may match at
+	// any level below that directory.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	for _, s := range strings.Split(line, "/") {
+		if s == "" {
+			continue // collapse repeated separators
+		}
+		p.segments = append(p.segments, s)
+	}
+	if !anchored && (len(p.segments) == 0 || p.segments[0] != "**") {
+		p.segments = append([]string{"**"}, p.segments...)
+	}
+	return p, true
+}
+
+// trimTrailingSpace removes trailing spaces from line unless they are
+// escaped with a backslash.
+func trimTrailingSpace(line string) string {
+	i := len(line)
+	for i > 0 && line[i-1] == ' ' {
+		i--
+	}
+	if i < len(line) && i > 0 && line[i-1] == '\\' && !escaped(line, i-1) {
+		// Keep the escaped space, the backslash is removed when matching.
+		i++
+	}
+	return line[:i]
+}
+
+// escaped reports whether the character at line[i] is itself escaped.
+func escaped(line string, i int) bool {
+	n := 0
+	for i > 0 && line[i-1] == '\\' {
+		n++
+		i--
+	}
+	return n%2 == 1
+}
+
+// Match reports whether the pattern matches the slash separated path,
+// relative to the root of the tree. The negation of the pattern is not
+// taken into account.
+func (p *Pattern) Match(path string, isDir bool) bool {
+	if p.DirOnly && !isDir {
+		return false
+	}
+	path = strings.Trim(path, "/")
+	if p.Base != "" {
+		if !strings.HasPrefix(path, p.Base+"/") {
+			return false
+		}
+		path = path[len(p.Base)+1:]
+	}
+	if path == "" {
+		return false
+	}
+	return matchSegments(p.segments, strings.Split(path, "/"))
+}
+
+// matchSegments matches the pattern segments against the path segments.
+// A "**" segment matches zero or more path segments, except when it is
+// the last segment of the pattern where it must match at least one.
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return len(path) > 0
+			}
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(rest, path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 || !matchName(pattern[0], path[0]) {
+			return false
+		}
+		pattern = pattern[1:]
+		path = path[1:]
+	}
+	return len(path) == 0
+}
+
+// matchName matches a single path segment against a wildcard pattern
+// containing '*', '?', bracket expressions and backslash escapes.
+func matchName(pattern, name string) bool {
+	// Position to resume from after the last '*' failed to match.
+	starP, starN := -1, -1
+	p, n := 0, 0
+	for n < len(name) {
+		if p < len(pattern) {
+			switch c := pattern[p]; c {
+			case '*':
+				for p < len(pattern) && pattern[p] == '*' {
+					p++
+				}
+				starP, starN = p, n
+				continue
+			case '?':
+				p++
+				n++
+				continue
+			case '[':
+				if ok, width, valid := matchClass(pattern[p:], name[n]); valid {
+					if ok {
+						p += width
+						n++
+						continue
+					}
+					break
+				}
+				// An unterminated bracket matches a literal '['.
+				if name[n] == '[' {
+					p++
+					n++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) {
+					c = pattern[p+1]
+					if name[n] == c {
+						p += 2
+						n++
+						continue
+					}
+					break
+				}
+				fallthrough
+			default:
+				if name[n] == c {
+					p++
+					n++
+					continue
+				}
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		starN++
+		p, n = starP, starN
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchClass matches c against the bracket expression at the start of
+// pattern. It returns whether c matched, the width of the expression and
+// whether the expression is well formed.
+func matchClass(pattern string, c byte) (matched bool, width int, valid bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+	for first := true; i < len(pattern); first = false {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+		i++
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi = pattern[i+1]
+			i += 2
+			if hi == '\\' && i < len(pattern) {
+				hi = pattern[i]
+				i++
+			}
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	return false, 0, false
+}
@@ -0,0 +1,26 @@
+//go:build dragonfly || freebsd || netbsd
+// +build dragonfly freebsd netbsd
+
+package fs
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func lchmod(name string, mode os.FileMode) error {
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return &os.PathError{Op: "lchmod", Path: name, Err: err}
+	}
+	_, _, e := syscall.Syscall(syscall.SYS_LCHMOD, uintptr(unsafe.Pointer(p)), uintptr(syscallMode(mode)), 0)
+	switch e {
+	case 0:
+		return nil
+	case syscall.EOPNOTSUPP:
+		// The file system does not support modes of symbolic links.
+		return &os.PathError{Op: "lchmod", Path: name, Err: ErrNotSupported}
+	}
+	return &os.PathError{Op: "lchmod", Path: name, Err: e}
+}
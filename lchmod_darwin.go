@@ -0,0 +1,32 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// fchmodat(2) and its flags, which are missing from package syscall.
+const (
+	_SYS_FCHMODAT        = 467
+	_AT_FDCWD            = -2
+	_AT_SYMLINK_NOFOLLOW = 0x20
+)
+
+func lchmod(name string, mode os.FileMode) error {
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return &os.PathError{Op: "lchmod", Path: name, Err: err}
+	}
+	dirfd := _AT_FDCWD
+	_, _, e := syscall.Syscall6(_SYS_FCHMODAT, uintptr(dirfd), uintptr(unsafe.Pointer(p)),
+		uintptr(syscallMode(mode)), _AT_SYMLINK_NOFOLLOW, 0, 0)
+	switch e {
+	case 0:
+		return nil
+	case syscall.EOPNOTSUPP:
+		// The file system does not support modes of symbolic links.
+		return &os.PathError{Op: "lchmod", Path: name, Err: ErrNotSupported}
+	}
+	return &os.PathError{Op: "lchmod", Path: name, Err: e}
+}
@@ -0,0 +1,60 @@
+package fs
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+func lchmod(name string, mode os.FileMode) error {
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return &os.PathError{Op: "lchmod", Path: name, Err: err}
+	}
+	dirfd := _AT_FDCWD
+	_, _, e := syscall.Syscall6(_SYS_FCHMODAT2, uintptr(dirfd), uintptr(unsafe.Pointer(p)),
+		uintptr(syscallMode(mode)), _AT_SYMLINK_NOFOLLOW, 0, 0)
+	switch e {
+	case 0:
+		return nil
+	case syscall.ENOSYS, syscall.EPERM:
+		// Kernels before 6.6 do not implement fchmodat2, and seccomp
+		// filters of containers may fail unknown system calls with
+		// EPERM. The mode of anything but a symbolic link can still be
+		// changed.
+		return lchmodProc(name, mode)
+	case syscall.EOPNOTSUPP:
+		// Linux does not support modes of symbolic links.
+		return &os.PathError{Op: "lchmod", Path: name, Err: ErrNotSupported}
+	}
+	return &os.PathError{Op: "lchmod", Path: name, Err: e}
+}
+
+// lchmodProc changes the mode of name unless it is a symbolic link, like
+// glibc does without fchmodat2: the file is opened without following
+// symbolic links and its mode changed through /proc/self/fd, so that it
+// can not be replaced by a symbolic link in between.
+func lchmodProc(name string, mode os.FileMode) error {
+	fd, err := syscall.Open(name, _O_PATH|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "lchmod", Path: name, Err: err}
+	}
+	defer syscall.Close(fd)
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		return &os.PathError{Op: "lchmod", Path: name, Err: err}
+	}
+	if st.Mode&syscall.S_IFMT == syscall.S_IFLNK {
+		return &os.PathError{Op: "lchmod", Path: name, Err: ErrNotSupported}
+	}
+	err = syscall.Chmod("/proc/self/fd/"+strconv.Itoa(fd), syscallMode(mode))
+	if err == syscall.ENOENT {
+		// /proc is not mounted.
+		err = ErrNotSupported
+	}
+	if err != nil {
+		return &os.PathError{Op: "lchmod", Path: name, Err: err}
+	}
+	return nil
+}
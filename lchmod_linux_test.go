@@ -0,0 +1,33 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLchmodProc(t *testing.T) {
+	dir := TempTree(t, map[string]string{"file": ""})
+	name := filepath.Join(dir, "file")
+	if err := lchmodProc(name, 0600); err != nil {
+		if errors.Is(err, ErrNotSupported) {
+			t.Skip(err)
+		}
+		t.Fatalf("lchmodProc: %v", err)
+	}
+	checkMode(t, name, 0600)
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink("file", link); err != nil {
+		t.Fatal(err)
+	}
+	if err := lchmodProc(link, 0644); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("lchmodProc(symlink): error = %v, want ErrNotSupported", err)
+	}
+	checkMode(t, name, 0600)
+
+	if err := lchmodProc(filepath.Join(dir, "missing"), 0644); !os.IsNotExist(err) {
+		t.Errorf("lchmodProc(missing): error = %v, want not exist", err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd
+
+package fs
+
+import "os"
+
+// lchmod changes the mode of name with Chmod, unless it is a symbolic
+// link. These platforms have no system call that changes the mode without
+// following symbolic links, so a file replaced by a symbolic link after
+// it is checked is followed.
+func lchmod(name string, mode os.FileMode) error {
+	fi, err := Lstat(name)
+	if err != nil {
+		return &os.PathError{Op: "lchmod", Path: name, Err: underlyingError(err)}
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return &os.PathError{Op: "lchmod", Path: name, Err: ErrNotSupported}
+	}
+	return Chmod(name, mode)
+}
@@ -0,0 +1,149 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// The locks placed by the functions below are advisory: they only
+// coordinate processes that use them and do not prevent access to the file.
+//
+// Whole file locks are flock(2) locks on Unix systems and LockFileEx locks
+// on Windows. Byte-range locks are open file description locks on Linux,
+// see fcntl(2), and LockFileEx locks on Windows, they are not supported
+// on other platforms. Both kinds of locks are associated with the open
+// file: they are shared by duplicated descriptors, conflict with locks
+// placed through other calls to Open, even by the same process, and are
+// released when the file is closed. On Linux whole file and byte-range
+// locks are independent of each other.
+
+// lockType is the kind of lock to place on a file.
+type lockType int
+
+const (
+	readLock  lockType = iota // shared lock
+	writeLock                 // exclusive lock
+)
+
+// Polling delays of the lock functions that take a Context.
+const (
+	minLockRetryDelay = time.Millisecond
+	maxLockRetryDelay = 100 * time.Millisecond
+)
+
+// Lock places an exclusive lock on f, waiting until it is available.
+// If there is an error, it will be of type *PathError.
+func Lock(f *os.File) error {
+	return lockContext(context.Background(), f, writeLock, 0, 0, false)
+}
+
+// RLock places a shared lock on f, waiting until it is available. Shared
+// locks may be held by several files at once, but not together with an
+// exclusive lock. If there is an error, it will be of type *PathError.
+func RLock(f *os.File) error {
+	return lockContext(context.Background(), f, readLock, 0, 0, false)
+}
+
+// LockContext is like Lock, but gives up when ctx is done. The error then
+// wraps the error of the Context.
+func LockContext(ctx context.Context, f *os.File) error {
+	return lockContext(ctx, f, writeLock, 0, 0, false)
+}
+
+// RLockContext is like RLock, but gives up when ctx is done. The error then
+// wraps the error of the Context.
+func RLockContext(ctx context.Context, f *os.File) error {
+	return lockContext(ctx, f, readLock, 0, 0, false)
+}
+
+// TryLock attempts to place an exclusive lock on f without waiting and
+// reports whether it succeeded.
+func TryLock(f *os.File) (bool, error) {
+	return lockFile(f, writeLock, false)
+}
+
+// TryRLock attempts to place a shared lock on f without waiting and reports
+// whether it succeeded.
+func TryRLock(f *os.File) (bool, error) {
+	return lockFile(f, readLock, false)
+}
+
+// Unlock releases the lock held on f.
+func Unlock(f *os.File) error {
+	return unlockFile(f)
+}
+
+// LockRange places a lock on the n bytes of f starting at off, waiting
+// until it is available or ctx is done. If n is zero, the range extends to
+// the end of the file, however large it grows. The lock is exclusive if
+// exclusive is true and shared otherwise. If the platform does not support
+// byte-range locks, the error wraps ErrNotSupported.
+func LockRange(ctx context.Context, f *os.File, off, n int64, exclusive bool) error {
+	return lockContext(ctx, f, rangeLockType(exclusive), off, n, true)
+}
+
+// TryLockRange is like LockRange, but does not wait for the lock and
+// reports whether it was placed.
+func TryLockRange(f *os.File, off, n int64, exclusive bool) (bool, error) {
+	return lockRange(f, rangeLockType(exclusive), off, n, false)
+}
+
+// UnlockRange releases the lock held on the n bytes of f starting at off.
+func UnlockRange(f *os.File, off, n int64) error {
+	return unlockRange(f, off, n)
+}
+
+func rangeLockType(exclusive bool) lockType {
+	if exclusive {
+		return writeLock
+	}
+	return readLock
+}
+
+// lockContext places a whole file lock, or a byte-range lock if isRange is
+// true. Locks that can not be cancelled by the system are polled for until
+// ctx is done.
+func lockContext(ctx context.Context, f *os.File, lt lockType, off, n int64, isRange bool) error {
+	try := func(wait bool) (bool, error) {
+		if isRange {
+			return lockRange(f, lt, off, n, wait)
+		}
+		return lockFile(f, lt, wait)
+	}
+	if ctx.Done() == nil {
+		_, err := try(true)
+		return err
+	}
+	delay := minLockRetryDelay
+	for {
+		ok, err := try(false)
+		if ok || err != nil {
+			return err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return &os.PathError{Op: "lock", Path: f.Name(), Err: ctx.Err()}
+		}
+		if delay *= 2; delay > maxLockRetryDelay {
+			delay = maxLockRetryDelay
+		}
+	}
+}
+
+// controlFd calls fn with the descriptor of f, which is guaranteed to
+// remain valid until fn returns.
+func controlFd(f *os.File, fn func(fd uintptr) error) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ferr error
+	if err := rc.Control(func(fd uintptr) { ferr = fn(fd) }); err != nil {
+		return err
+	}
+	return ferr
+}
@@ -0,0 +1,53 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+func lockFile(f *os.File, lt lockType, wait bool) (bool, error) {
+	how := syscall.LOCK_EX
+	if lt == readLock {
+		how = syscall.LOCK_SH
+	}
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	err := controlFd(f, func(fd uintptr) error {
+		return ignoringEINTR(func() error {
+			return syscall.Flock(int(fd), how)
+		})
+	})
+	if err != nil {
+		if !wait && err == syscall.EWOULDBLOCK {
+			return false, nil
+		}
+		return false, &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	err := controlFd(f, func(fd uintptr) error {
+		return ignoringEINTR(func() error {
+			return syscall.Flock(int(fd), syscall.LOCK_UN)
+		})
+	})
+	if err != nil {
+		return &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+	return nil
+}
+
+// ignoringEINTR calls fn, retrying it if it is interrupted by a signal.
+func ignoringEINTR(fn func() error) error {
+	for {
+		err := fn()
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package fs
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// Open file description lock commands, see fcntl(2).
+const (
+	_F_OFD_GETLK  = 36
+	_F_OFD_SETLK  = 37
+	_F_OFD_SETLKW = 38
+)
+
+func lockRange(f *os.File, lt lockType, off, n int64, wait bool) (bool, error) {
+	lk := syscall.Flock_t{
+		Type:   syscall.F_WRLCK,
+		Whence: io.SeekStart,
+		Start:  off,
+		Len:    n,
+	}
+	if lt == readLock {
+		lk.Type = syscall.F_RDLCK
+	}
+	cmd := _F_OFD_SETLK
+	if wait {
+		cmd = _F_OFD_SETLKW
+	}
+	err := controlFd(f, func(fd uintptr) error {
+		return ignoringEINTR(func() error {
+			return syscall.FcntlFlock(fd, cmd, &lk)
+		})
+	})
+	if err != nil {
+		if !wait && (err == syscall.EAGAIN || err == syscall.EACCES) {
+			return false, nil
+		}
+		if err == syscall.EINVAL {
+			// Kernels older than 3.15 do not support OFD locks.
+			err = ErrNotSupported
+		}
+		return false, &os.PathError{Op: "fcntl", Path: f.Name(), Err: err}
+	}
+	return true, nil
+}
+
+func unlockRange(f *os.File, off, n int64) error {
+	lk := syscall.Flock_t{
+		Type:   syscall.F_UNLCK,
+		Whence: io.SeekStart,
+		Start:  off,
+		Len:    n,
+	}
+	err := controlFd(f, func(fd uintptr) error {
+		return ignoringEINTR(func() error {
+			return syscall.FcntlFlock(fd, _F_OFD_SETLK, &lk)
+		})
+	})
+	if err != nil {
+		return &os.PathError{Op: "fcntl", Path: f.Name(), Err: err}
+	}
+	return nil
+}
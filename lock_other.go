@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package fs
+
+import "os"
+
+func lockFile(f *os.File, lt lockType, wait bool) (bool, error) {
+	return false, &os.PathError{Op: "lock", Path: f.Name(), Err: ErrNotSupported}
+}
+
+func unlockFile(f *os.File) error {
+	return &os.PathError{Op: "unlock", Path: f.Name(), Err: ErrNotSupported}
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package fs
+
+import "os"
+
+func lockRange(f *os.File, lt lockType, off, n int64, wait bool) (bool, error) {
+	return false, &os.PathError{Op: "lock", Path: f.Name(), Err: ErrNotSupported}
+}
+
+func unlockRange(f *os.File, off, n int64) error {
+	return &os.PathError{Op: "unlock", Path: f.Name(), Err: ErrNotSupported}
+}
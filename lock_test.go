@@ -0,0 +1,257 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The lock tests run the test binary as a child process, which attempts to
+// lock the file named by $FS_TEST_LOCK_FILE with the operation named by
+// $FS_TEST_LOCK_OP and prints the result.
+
+func TestLockHelperProcess(t *testing.T) {
+	name := os.Getenv("FS_TEST_LOCK_FILE")
+	if name == "" {
+		t.Skip("helper process")
+	}
+	op := strings.Split(os.Getenv("FS_TEST_LOCK_OP"), ":")
+	if strings.HasPrefix(op[0], "lockfile") {
+		lockFileHelper(name, op[0])
+	}
+	f, err := OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	var ok bool
+	switch op[0] {
+	case "trylock":
+		ok, err = TryLock(f)
+	case "tryrlock":
+		ok, err = TryRLock(f)
+	case "lock":
+		ok, err = true, Lock(f)
+	case "trylockrange":
+		off, _ := strconv.ParseInt(op[1], 10, 64)
+		n, _ := strconv.ParseInt(op[2], 10, 64)
+		ok, err = TryLockRange(f, off, n, op[3] == "x")
+	default:
+		err = fmt.Errorf("invalid operation: %q", op)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println(ok)
+	os.Exit(0)
+}
+
+func lockHelper(t *testing.T, name, op string) *osexec.Cmd {
+	cmd := osexec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+	cmd.Env = append(os.Environ(), "FS_TEST_LOCK_FILE="+name, "FS_TEST_LOCK_OP="+op)
+	return cmd
+}
+
+// childLock runs op in a child process and returns whether it acquired
+// the lock.
+func childLock(t *testing.T, name, op string) bool {
+	t.Helper()
+	out, err := lockHelper(t, name, op).CombinedOutput()
+	if err != nil {
+		t.Fatalf("helper %s: %v: %s", op, err, out)
+	}
+	ok, err := strconv.ParseBool(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatalf("helper %s: %s", op, out)
+	}
+	return ok
+}
+
+func lockTestFile(t *testing.T) *os.File {
+	f := newFile("TestLock", t)
+	t.Cleanup(func() {
+		f.Close()
+		Remove(f.Name())
+	})
+	if _, err := f.WriteString("0123456789abcdefghij"); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func skipIfNoSubprocess(t *testing.T) {
+	switch runtime.GOOS {
+	case "android", "ios", "js", "wasip1":
+		t.Skipf("subprocesses are not supported on %s", runtime.GOOS)
+	}
+}
+
+func TestLockExclusion(t *testing.T) {
+	skipIfNoSubprocess(t)
+	f := lockTestFile(t)
+
+	if err := Lock(f); err != nil {
+		t.Fatal(err)
+	}
+	if childLock(t, f.Name(), "trylock") {
+		t.Error("child acquired an exclusive lock held by the parent")
+	}
+	if childLock(t, f.Name(), "tryrlock") {
+		t.Error("child acquired a shared lock while the parent holds an exclusive lock")
+	}
+	if err := Unlock(f); err != nil {
+		t.Fatal(err)
+	}
+	if !childLock(t, f.Name(), "trylock") {
+		t.Error("child failed to acquire an exclusive lock after Unlock")
+	}
+
+	if err := RLock(f); err != nil {
+		t.Fatal(err)
+	}
+	if !childLock(t, f.Name(), "tryrlock") {
+		t.Error("child failed to acquire a shared lock held by the parent")
+	}
+	if childLock(t, f.Name(), "trylock") {
+		t.Error("child acquired an exclusive lock while the parent holds a shared lock")
+	}
+	if err := Unlock(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockWait(t *testing.T) {
+	skipIfNoSubprocess(t)
+	f := lockTestFile(t)
+	if err := Lock(f); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := lockHelper(t, f.Name(), "lock")
+	var out strings.Builder
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("child acquired the lock held by the parent: %v: %s", err, out.String())
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := Unlock(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("helper: %v: %s", err, out.String())
+	}
+}
+
+func TestLockContext(t *testing.T) {
+	f := lockTestFile(t)
+	// Locks conflict between files opened separately by the same process.
+	f2, err := Open(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f2.Close()
+
+	if err := Lock(f); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := TryLock(f2); ok || err != nil {
+		t.Fatalf("TryLock: %t, %v; want: false, <nil>", ok, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = LockContext(ctx, f2)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("LockContext: got: %v want: %v", err, context.DeadlineExceeded)
+	}
+	if _, ok := err.(*os.PathError); !ok {
+		t.Errorf("LockContext: error type %T is not *os.PathError", err)
+	}
+
+	unlocked := make(chan error, 1)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		unlocked <- Unlock(f)
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), watchTimeout)
+	defer cancel()
+	if err := RLockContext(ctx, f2); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-unlocked; err != nil {
+		t.Fatal(err)
+	}
+	if err := Unlock(f2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockRange(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		f := lockTestFile(t)
+		if _, err := TryLockRange(f, 0, 10, true); !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("TryLockRange: got: %v want: %v", err, ErrNotSupported)
+		}
+		return
+	}
+	skipIfNoSubprocess(t)
+	f := lockTestFile(t)
+
+	if err := LockRange(context.Background(), f, 0, 10, true); err != nil {
+		if errors.Is(err, ErrNotSupported) {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	if err := LockRange(context.Background(), f, 10, 5, false); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		op   string
+		want bool
+	}{
+		{"trylockrange:0:10:x", false},
+		{"trylockrange:5:10:s", false},
+		{"trylockrange:10:5:s", true},
+		{"trylockrange:10:5:x", false},
+		{"trylockrange:15:5:x", true},
+	}
+	for _, test := range tests {
+		if got := childLock(t, f.Name(), test.op); got != test.want {
+			t.Errorf("%s: got: %t want: %t", test.op, got, test.want)
+		}
+	}
+
+	if err := UnlockRange(f, 0, 10); err != nil {
+		t.Fatal(err)
+	}
+	if !childLock(t, f.Name(), "trylockrange:0:10:x") {
+		t.Error("child failed to lock the range after UnlockRange")
+	}
+
+	f2, err := OpenFile(f.Name(), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f2.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := LockRange(ctx, f2, 12, 1, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LockRange: got: %v want: %v", err, context.DeadlineExceeded)
+	}
+}
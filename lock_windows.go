@@ -0,0 +1,93 @@
+package fs
+
+import (
+	"math"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	_LOCKFILE_FAIL_IMMEDIATELY = 0x00000001
+	_LOCKFILE_EXCLUSIVE_LOCK   = 0x00000002
+
+	_ERROR_LOCK_VIOLATION syscall.Errno = 33
+)
+
+func lockFileEx(h syscall.Handle, flags uint32, off, n int64) error {
+	if n == 0 {
+		n = math.MaxInt64 - off
+	}
+	ol := syscall.Overlapped{
+		Offset:     uint32(off),
+		OffsetHigh: uint32(off >> 32),
+	}
+	r1, _, err := procLockFileEx.Call(uintptr(h), uintptr(flags), 0,
+		uintptr(uint32(n)), uintptr(uint32(n>>32)), uintptr(unsafe.Pointer(&ol)))
+	if r1 == 0 {
+		return err
+	}
+	return nil
+}
+
+func unlockFileEx(h syscall.Handle, off, n int64) error {
+	if n == 0 {
+		n = math.MaxInt64 - off
+	}
+	ol := syscall.Overlapped{
+		Offset:     uint32(off),
+		OffsetHigh: uint32(off >> 32),
+	}
+	r1, _, err := procUnlockFileEx.Call(uintptr(h), 0,
+		uintptr(uint32(n)), uintptr(uint32(n>>32)), uintptr(unsafe.Pointer(&ol)))
+	if r1 == 0 {
+		return err
+	}
+	return nil
+}
+
+func lockRange(f *os.File, lt lockType, off, n int64, wait bool) (bool, error) {
+	var flags uint32
+	if lt == writeLock {
+		flags |= _LOCKFILE_EXCLUSIVE_LOCK
+	}
+	if !wait {
+		flags |= _LOCKFILE_FAIL_IMMEDIATELY
+	}
+	err := controlFd(f, func(fd uintptr) error {
+		return lockFileEx(syscall.Handle(fd), flags, off, n)
+	})
+	if err != nil {
+		if !wait && err == _ERROR_LOCK_VIOLATION {
+			return false, nil
+		}
+		return false, &os.PathError{Op: "LockFileEx", Path: f.Name(), Err: err}
+	}
+	return true, nil
+}
+
+func unlockRange(f *os.File, off, n int64) error {
+	err := controlFd(f, func(fd uintptr) error {
+		return unlockFileEx(syscall.Handle(fd), off, n)
+	})
+	if err != nil {
+		return &os.PathError{Op: "UnlockFileEx", Path: f.Name(), Err: err}
+	}
+	return nil
+}
+
+// Whole file locks lock the largest possible range.
+
+func lockFile(f *os.File, lt lockType, wait bool) (bool, error) {
+	return lockRange(f, lt, 0, 0, wait)
+}
+
+func unlockFile(f *os.File) error {
+	return unlockRange(f, 0, 0)
+}
@@ -0,0 +1,246 @@
+package fs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned when a lock file is held by another process.
+var ErrLocked = errors.New("fs: lock file is held by another process")
+
+// lockFileGrace is how long an incomplete lock file, which is being created
+// by another process, is considered to be valid.
+const lockFileGrace = 10 * time.Second
+
+// A LockOwner identifies the process that holds a lock file.
+type LockOwner struct {
+	PID     int       // process ID
+	Host    string    // host name
+	Started time.Time // time at which the lock was acquired
+}
+
+// Alive reports whether the owner process is still running. Processes of
+// other hosts are assumed to be running.
+func (o LockOwner) Alive() bool {
+	if host, err := os.Hostname(); err != nil || host != o.Host {
+		return true
+	}
+	return processExists(o.PID)
+}
+
+func (o LockOwner) String() string {
+	return fmt.Sprintf("pid %d on %s since %s", o.PID, o.Host, o.Started.Format(time.RFC3339))
+}
+
+// A LockFile is a file whose existence signals that a resource, such as a
+// service instance, is in use by the process recorded in it.
+//
+// The file is created atomically with O_EXCL and holds the process ID,
+// host name and time at which it was created, in that order, each on its
+// own line. A lock, see Lock, is held on the file for as long as it is
+// held so that, when the owner exits without releasing it, other processes
+// can tell that the file is stale.
+type LockFile struct {
+	name  string
+	f     *os.File
+	owner LockOwner
+}
+
+// CreateLockFile creates the lock file name and returns it. If the file
+// already exists and is held by another process, the error wraps
+// ErrLocked. A stale lock file left behind by a process that exited
+// without releasing it is removed and created anew.
+// If there is an error, it will be of type *PathError.
+func CreateLockFile(name string) (*LockFile, error) {
+	// Another process may be breaking the same stale lock, in which case
+	// creating the file is retried.
+	for i := 0; i < 10; i++ {
+		f, err := OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return newLockFile(name, f)
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		broken, err := breakLockFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if !broken {
+			break
+		}
+	}
+	return nil, &os.PathError{Op: "lock", Path: name, Err: ErrLocked}
+}
+
+func newLockFile(name string, f *os.File) (*LockFile, error) {
+	cleanup := func(err error) (*LockFile, error) {
+		removeLockFile(name, f)
+		return nil, err
+	}
+	// Another process checking whether the file is stale may hold the
+	// lock between the file being created and locked, it releases it
+	// once it finds the file incomplete.
+	deadline := time.Now().Add(lockFileGrace)
+	for {
+		ok, err := TryLock(f)
+		if err != nil {
+			return cleanup(err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return cleanup(&os.PathError{Op: "lock", Path: name, Err: ErrLocked})
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return cleanup(err)
+	}
+	l := &LockFile{
+		name: name,
+		f:    f,
+		owner: LockOwner{
+			PID:     os.Getpid(),
+			Host:    host,
+			Started: time.Now().Round(0),
+		},
+	}
+	data := fmt.Sprintf("%d\n%s\n%s\n", l.owner.PID, l.owner.Host,
+		l.owner.Started.Format(time.RFC3339Nano))
+	if _, err := f.WriteString(data); err != nil {
+		return cleanup(err)
+	}
+	if err := f.Sync(); err != nil {
+		return cleanup(err)
+	}
+	return l, nil
+}
+
+// breakLockFile removes the lock file name if it is stale, it reports
+// whether the file no longer exists.
+func breakLockFile(name string) (bool, error) {
+	f, err := Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	defer f.Close() // releases the lock, unless closed by removeLockFile
+	// The exclusive lock also prevents concurrent attempts to break the
+	// lock from removing the file created by the first one to succeed.
+	ok, err := TryLock(f)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// The file may have been removed, and created again, by another
+	// process breaking the lock.
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	cur, err := Stat(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if !os.SameFile(fi, cur) {
+		return true, nil
+	}
+	if _, err := readLockOwner(f); err != nil && time.Since(fi.ModTime()) < lockFileGrace {
+		// The owner is between creating and locking the file.
+		return false, nil
+	}
+	if err := removeLockFile(name, f); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// removeLockFile removes the lock file name and closes f, the open file.
+// The file is removed before it is unlocked, so that it can not be
+// mistaken for a stale lock file, except on Windows, where open files
+// can not be removed. There, a lock file recreated by another process in
+// between can not be removed either, as it is held open by its owner.
+func removeLockFile(name string, f *os.File) error {
+	if !canRemoveOpen {
+		err := f.Close()
+		if rerr := Remove(name); rerr != nil {
+			err = rerr
+		}
+		return err
+	}
+	err := Remove(name)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Name returns the name of the lock file.
+func (l *LockFile) Name() string { return l.name }
+
+// Owner returns the owner recorded in the lock file, the current process.
+func (l *LockFile) Owner() LockOwner { return l.owner }
+
+// Release removes the lock file and releases its lock.
+func (l *LockFile) Release() error {
+	if l.f == nil {
+		return &os.PathError{Op: "release", Path: l.name, Err: os.ErrClosed}
+	}
+	err := removeLockFile(l.name, l.f)
+	l.f = nil
+	return err
+}
+
+// ReadLockFile returns the owner recorded in the lock file name.
+// If there is an error, it will be of type *PathError.
+func ReadLockFile(name string) (LockOwner, error) {
+	f, err := Open(name)
+	if err != nil {
+		return LockOwner{}, err
+	}
+	defer f.Close()
+	o, err := readLockOwner(f)
+	if err != nil {
+		return LockOwner{}, &os.PathError{Op: "read", Path: name, Err: err}
+	}
+	return o, nil
+}
+
+var errInvalidLockFile = errors.New("invalid lock file")
+
+func readLockOwner(r io.Reader) (LockOwner, error) {
+	var lines []string
+	sc := bufio.NewScanner(io.LimitReader(r, 4096))
+	for sc.Scan() {
+		lines = append(lines, strings.TrimSpace(sc.Text()))
+	}
+	if err := sc.Err(); err != nil {
+		return LockOwner{}, err
+	}
+	if len(lines) < 3 {
+		return LockOwner{}, errInvalidLockFile
+	}
+	pid, err := strconv.Atoi(lines[0])
+	if err != nil {
+		return LockOwner{}, errInvalidLockFile
+	}
+	started, err := time.Parse(time.RFC3339Nano, lines[2])
+	if err != nil {
+		return LockOwner{}, errInvalidLockFile
+	}
+	return LockOwner{PID: pid, Host: lines[1], Started: started}, nil
+}
@@ -0,0 +1,226 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lockFileHelper is run by TestLockHelperProcess for the lock file
+// operations, it prints the PID recorded in the lock file or the error.
+func lockFileHelper(name, op string) {
+	l, err := CreateLockFile(name)
+	if err != nil {
+		fmt.Println(err)
+		if errors.Is(err, ErrLocked) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+	fmt.Println(l.Owner().PID)
+	if op == "lockfile-crash" {
+		// Exit without releasing the lock.
+		os.Exit(0)
+	}
+	if err := l.Release(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func lockFileName(t *testing.T) string {
+	dir := newDir("TestLockFile", t)
+	t.Cleanup(func() { RemoveAll(dir) })
+	return filepath.Join(dir, "test.pid")
+}
+
+func TestLockFile(t *testing.T) {
+	name := lockFileName(t)
+	l, err := CreateLockFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := ReadLockFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.PID != os.Getpid() {
+		t.Errorf("PID: got: %d want: %d", owner.PID, os.Getpid())
+	}
+	if host, _ := os.Hostname(); owner.Host != host {
+		t.Errorf("Host: got: %q want: %q", owner.Host, host)
+	}
+	if !owner.Started.Equal(l.Owner().Started) {
+		t.Errorf("Started: got: %s want: %s", owner.Started, l.Owner().Started)
+	}
+	if !owner.Alive() {
+		t.Error("Alive: the current process is not alive")
+	}
+
+	// The lock is held by the same process through another file.
+	if _, err := CreateLockFile(name); !errors.Is(err, ErrLocked) {
+		t.Fatalf("CreateLockFile: got: %v want: %v", err, ErrLocked)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Stat(name); !os.IsNotExist(err) {
+		t.Errorf("Stat after Release: %v", err)
+	}
+	if err := l.Release(); err == nil {
+		t.Error("expected an error releasing a LockFile twice")
+	}
+}
+
+func TestLockFileIncomplete(t *testing.T) {
+	name := lockFileName(t)
+	// An empty lock file is being created by another process.
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateLockFile(name); !errors.Is(err, ErrLocked) {
+		t.Fatalf("CreateLockFile: got: %v want: %v", err, ErrLocked)
+	}
+	// Unless it is too old.
+	old := time.Now().Add(-2 * lockFileGrace)
+	if err := Chtimes(name, old, old); err != nil {
+		t.Fatal(err)
+	}
+	l, err := CreateLockFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Release()
+}
+
+func TestLockFileProbed(t *testing.T) {
+	name := lockFileName(t)
+	f, err := OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another process checks whether the new, still empty, file is stale
+	// before it is locked by its creator.
+	probe, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := TryLock(probe); !ok || err != nil {
+		t.Fatalf("TryLock: %t, %v", ok, err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() { probe.Close() })
+	l, err := newLockFile(name, f)
+	if err != nil {
+		t.Fatalf("newLockFile: %v", err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runLockFileHelper(t *testing.T, name, op string) (pid int, locked bool) {
+	t.Helper()
+	out, err := lockHelper(t, name, op).CombinedOutput()
+	if err != nil {
+		if e, ok := err.(interface{ ExitCode() int }); ok && e.ExitCode() == 2 {
+			return 0, true
+		}
+		t.Fatalf("helper %s: %v: %s", op, err, out)
+	}
+	pid, err = strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatalf("helper %s: %s", op, out)
+	}
+	return pid, false
+}
+
+func TestLockFileProcesses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("subprocess lock file tests are only run on linux")
+	}
+	name := lockFileName(t)
+
+	// A lock file held by a running process.
+	l, err := CreateLockFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, locked := runLockFileHelper(t, name, "lockfile"); !locked {
+		t.Error("child created a lock file held by the parent")
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A lock file released by a child.
+	if _, locked := runLockFileHelper(t, name, "lockfile"); locked {
+		t.Fatal("child could not create the lock file")
+	}
+	if _, err := Stat(name); !os.IsNotExist(err) {
+		t.Fatalf("lock file not removed by the child: %v", err)
+	}
+
+	// A stale lock file left behind by a child that exited.
+	pid, locked := runLockFileHelper(t, name, "lockfile-crash")
+	if locked {
+		t.Fatal("child could not create the lock file")
+	}
+	owner, err := ReadLockFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.PID != pid {
+		t.Errorf("PID: got: %d want: %d", owner.PID, pid)
+	}
+	if owner.Alive() {
+		t.Errorf("owner %s of the stale lock file is alive", owner)
+	}
+	l, err = CreateLockFile(name)
+	if err != nil {
+		t.Fatalf("breaking stale lock file: %v", err)
+	}
+	defer l.Release()
+	if owner, err := ReadLockFile(name); err != nil || owner.PID != os.Getpid() {
+		t.Errorf("ReadLockFile: got: %+v, %v want PID: %d", owner, err, os.Getpid())
+	}
+}
+
+func TestLockFileConcurrent(t *testing.T) {
+	name := lockFileName(t)
+	const n = 8
+	type result struct {
+		l   *LockFile
+		err error
+	}
+	results := make(chan result, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			l, err := CreateLockFile(name)
+			results <- result{l, err}
+		}()
+	}
+	held := 0
+	for i := 0; i < n; i++ {
+		r := <-results
+		if r.err != nil {
+			if !errors.Is(r.err, ErrLocked) {
+				t.Error(r.err)
+			}
+			continue
+		}
+		held++
+		defer r.l.Release()
+	}
+	if held != 1 {
+		t.Errorf("lock file held %d times", held)
+	}
+}
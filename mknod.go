@@ -0,0 +1,21 @@
+package fs
+
+import "os"
+
+// Mkfifo creates a named pipe with the specified name and permission bits
+// (before umask).
+// If there is an error, it will be of type *PathError.
+func Mkfifo(name string, perm os.FileMode) error {
+	return mkfifo(name, perm)
+}
+
+// Mknod creates a file system node with the specified name. The type of the
+// node is given by the type bits of mode: a regular file if none is set,
+// os.ModeNamedPipe, os.ModeSocket, os.ModeDevice for block devices or
+// os.ModeDevice|os.ModeCharDevice for character devices. The device number
+// dev, see Makedev, is only used for devices, creating them usually
+// requires special privileges.
+// If there is an error, it will be of type *PathError.
+func Mknod(name string, mode os.FileMode, dev uint64) error {
+	return mknod(name, mode, dev)
+}
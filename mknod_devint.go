@@ -0,0 +1,12 @@
+//go:build darwin || dragonfly || linux || netbsd || openbsd
+// +build darwin dragonfly linux netbsd openbsd
+
+package fs
+
+import "syscall"
+
+// sysMknod calls syscall.Mknod, which takes the device number as an int on
+// all platforms but FreeBSD.
+func sysMknod(name string, mode uint32, dev uint64) error {
+	return syscall.Mknod(name, mode, int(dev))
+}
@@ -0,0 +1,7 @@
+package fs
+
+import "syscall"
+
+func sysMknod(name string, mode uint32, dev uint64) error {
+	return syscall.Mknod(name, mode, dev)
+}
@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package fs
+
+import "os"
+
+func mkfifo(name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkfifo", Path: name, Err: ErrNotSupported}
+}
+
+func mknod(name string, mode os.FileMode, dev uint64) error {
+	return &os.PathError{Op: "mknod", Path: name, Err: ErrNotSupported}
+}
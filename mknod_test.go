@@ -0,0 +1,137 @@
+package fs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMakedev(t *testing.T) {
+	tests := []struct{ major, minor uint32 }{
+		{0, 0},
+		{1, 3},
+		{8, 1},
+		{253, 0},
+		{0xff, 0xffff},
+		{1, 0x10045},
+	}
+	for _, test := range tests {
+		dev := Makedev(test.major, test.minor)
+		if major, minor := Major(dev), Minor(dev); major != test.major || minor != test.minor {
+			t.Errorf("Makedev(%d, %d) = %#x, split into %d, %d", test.major, test.minor, dev, major, minor)
+		}
+	}
+}
+
+func TestMkfifo(t *testing.T) {
+	name := filepath.Join(TempTree(t, nil), "fifo")
+	if err := Mkfifo(name, 0600); err != nil {
+		if errors.Is(err, ErrNotSupported) {
+			t.Skip(err)
+		}
+		t.Fatalf("Mkfifo: %v", err)
+	}
+	fi, err := Lstat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeType != os.ModeNamedPipe || fi.Mode().Perm() != 0600 {
+		t.Errorf("Mkfifo: mode = %s, want %s", fi.Mode(), os.ModeNamedPipe|0600)
+	}
+	if err := Mkfifo(name, 0600); !os.IsExist(err) {
+		t.Errorf("Mkfifo of existing file: error = %v, want exist", err)
+	}
+
+	// Opening either end blocks until the other one is opened.
+	const msg = "hello, fifo"
+	errc := make(chan error, 1)
+	go func() {
+		w, err := OpenFile(name, os.O_WRONLY, 0)
+		if err != nil {
+			errc <- err
+			return
+		}
+		_, err = io.WriteString(w, msg)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		errc <- err
+	}()
+	r, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != msg {
+		t.Errorf("read %q from fifo, want %q", got, msg)
+	}
+}
+
+func TestMknod(t *testing.T) {
+	dir := TempTree(t, nil)
+	tests := []struct {
+		name string
+		mode os.FileMode
+	}{
+		{"file", 0640},
+		{"fifo", os.ModeNamedPipe | 0600},
+	}
+	for _, test := range tests {
+		name := filepath.Join(dir, test.name)
+		if err := Mknod(name, test.mode, 0); err != nil {
+			if errors.Is(err, ErrNotSupported) {
+				t.Skip(err)
+			}
+			t.Fatalf("Mknod(%s, %s): %v", test.name, test.mode, err)
+		}
+		fi, err := Lstat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode() != test.mode {
+			t.Errorf("Mknod(%s): mode = %s, want %s", test.name, fi.Mode(), test.mode)
+		}
+	}
+
+	err := Mknod(filepath.Join(dir, "dir"), os.ModeDir|0755, 0)
+	var pe *os.PathError
+	if err == nil || !errors.As(err, &pe) || pe.Op != "mknod" {
+		t.Errorf("Mknod of directory: error = %v, want *PathError", err)
+	}
+}
+
+func TestMknodDevice(t *testing.T) {
+	fi, err := Stat(os.DevNull)
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		t.Skipf("%s is not a character device", os.DevNull)
+	}
+	st, ok := FileStatx(fi)
+	if !ok {
+		t.Skip("device numbers are not supported")
+	}
+	name := filepath.Join(TempTree(t, nil), "null")
+	err = Mknod(name, os.ModeDevice|os.ModeCharDevice|0666, Makedev(st.RdevMajor, st.RdevMinor))
+	if err != nil {
+		if os.IsPermission(err) || errors.Is(err, ErrNotSupported) {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	f, err := OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err) // e.g. a file system mounted with nodev
+	}
+	defer f.Close()
+	if _, err := f.WriteString("discarded"); err != nil {
+		t.Errorf("write to %s: %v", name, err)
+	}
+}
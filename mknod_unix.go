@@ -0,0 +1,53 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+func mkfifo(name string, perm os.FileMode) error {
+	if err := syscall.Mkfifo(name, syscallMode(perm)); err != nil {
+		return &os.PathError{Op: "mkfifo", Path: name, Err: err}
+	}
+	return nil
+}
+
+func mknod(name string, mode os.FileMode, dev uint64) error {
+	var typ uint32
+	switch mode & os.ModeType {
+	case 0:
+		typ = syscall.S_IFREG
+	case os.ModeNamedPipe:
+		typ = syscall.S_IFIFO
+	case os.ModeSocket:
+		typ = syscall.S_IFSOCK
+	case os.ModeDevice:
+		typ = syscall.S_IFBLK
+	case os.ModeDevice | os.ModeCharDevice:
+		typ = syscall.S_IFCHR
+	default:
+		return &os.PathError{Op: "mknod", Path: name, Err: syscall.EINVAL}
+	}
+	if err := sysMknod(name, typ|syscallMode(mode), dev); err != nil {
+		return &os.PathError{Op: "mknod", Path: name, Err: err}
+	}
+	return nil
+}
+
+// syscallMode returns the syscall-specific mode bits of mode.
+func syscallMode(mode os.FileMode) uint32 {
+	m := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		m |= syscall.S_ISUID
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= syscall.S_ISGID
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= syscall.S_ISVTX
+	}
+	return m
+}
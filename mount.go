@@ -0,0 +1,219 @@
+package fs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MountInfo describes a mount, as listed in /proc/self/mountinfo.
+type MountInfo struct {
+	ID           int      // unique ID of the mount
+	ParentID     int      // ID of the parent mount, or of itself for the root of the tree
+	Major, Minor uint32   // device number of the files in the mount
+	Root         string   // directory of the file system mounted, not "/" for bind mounts of a subdirectory
+	MountPoint   string   // path of the mount
+	Options      string   // per-mount options, e.g. "rw,nosuid"
+	Optional     []string // optional fields, e.g. "shared:1" or "master:2"
+	FSType       string   // file system type, e.g. "ext4"
+	Source       string   // file system specific source, e.g. "/dev/sda1"
+	SuperOptions string   // per-superblock options
+	Flags        MountFlags
+}
+
+// mountOptionFlags maps mount options to flags.
+var mountOptionFlags = map[string]MountFlags{
+	"ro":       MountReadOnly,
+	"nosuid":   MountNoSuid,
+	"nodev":    MountNoDev,
+	"noexec":   MountNoExec,
+	"sync":     MountSync,
+	"noatime":  MountNoAtime,
+	"relatime": MountRelatime,
+}
+
+// ParseMountInfo parses a mount table in the format of
+// /proc/self/mountinfo, see proc(5).
+func ParseMountInfo(r io.Reader) ([]*MountInfo, error) {
+	var mounts []*MountInfo
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		m, err := parseMountInfoLine(s.Text())
+		if err != nil {
+			return nil, fmt.Errorf("fs: invalid mountinfo line %d: %v", line, err)
+		}
+		mounts = append(mounts, m)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+func parseMountInfoLine(line string) (*MountInfo, error) {
+	// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+	fields := strings.Fields(line)
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 || len(fields) < sep+3 {
+		return nil, fmt.Errorf("%q: missing fields", line)
+	}
+
+	m := &MountInfo{
+		Root:       unescapeMountField(fields[3]),
+		MountPoint: unescapeMountField(fields[4]),
+		Options:    fields[5],
+		FSType:     unescapeMountField(fields[sep+1]),
+		Source:     unescapeMountField(fields[sep+2]),
+	}
+	if len(fields) > sep+3 {
+		m.SuperOptions = fields[sep+3]
+	}
+	if sep > 6 {
+		m.Optional = fields[6:sep]
+	}
+
+	var err error
+	if m.ID, err = strconv.Atoi(fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid mount ID %q", fields[0])
+	}
+	if m.ParentID, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, fmt.Errorf("invalid parent ID %q", fields[1])
+	}
+	i := strings.IndexByte(fields[2], ':')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid device %q", fields[2])
+	}
+	major, err1 := strconv.ParseUint(fields[2][:i], 10, 32)
+	minor, err2 := strconv.ParseUint(fields[2][i+1:], 10, 32)
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("invalid device %q", fields[2])
+	}
+	m.Major, m.Minor = uint32(major), uint32(minor)
+
+	for _, opts := range []string{m.Options, m.SuperOptions} {
+		for _, opt := range strings.Split(opts, ",") {
+			m.Flags |= mountOptionFlags[opt]
+		}
+	}
+	return m, nil
+}
+
+// unescapeMountField replaces the octal escapes used for spaces, tabs,
+// newlines and backslashes in the fields of the mount table.
+func unescapeMountField(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			b = append(b, (s[i+1]-'0')<<6|(s[i+2]-'0')<<3|(s[i+3]-'0'))
+			i += 3
+			continue
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+func isOctal(c byte) bool { return '0' <= c && c <= '7' }
+
+// Mounts returns the mounts visible to the current process. It is only
+// supported on Linux, on other platforms the error wraps ErrNotSupported.
+func Mounts() ([]*MountInfo, error) {
+	return mounts()
+}
+
+// MountOf returns the mount containing the named file. Symbolic links in
+// name are resolved.
+// If there is an error, it will be of type *PathError.
+func MountOf(name string) (*MountInfo, error) {
+	path, err := resolvePath(name)
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := Mounts()
+	if err != nil {
+		return nil, &os.PathError{Op: "mountof", Path: name, Err: err}
+	}
+	m := mountOf(mounts, path)
+	if m == nil {
+		return nil, &os.PathError{Op: "mountof", Path: name, Err: os.ErrNotExist}
+	}
+	return m, nil
+}
+
+// mountOf returns the mount containing the absolute, clean path. If
+// several file systems are mounted on the same directory the last one
+// hides the others.
+func mountOf(mounts []*MountInfo, path string) *MountInfo {
+	var best *MountInfo
+	for _, m := range mounts {
+		if m.MountPoint != path && !underDir(path, m.MountPoint) &&
+			!(m.MountPoint == "/" && strings.HasPrefix(path, "/")) {
+			continue
+		}
+		if best == nil || len(m.MountPoint) >= len(best.MountPoint) {
+			best = m
+		}
+	}
+	return best
+}
+
+// IsMountPoint reports whether the named directory is the root of a mount,
+// including bind mounts. Symbolic links in name are resolved.
+// If there is an error, it will be of type *PathError.
+func IsMountPoint(name string) (bool, error) {
+	path, err := resolvePath(name)
+	if err != nil {
+		return false, err
+	}
+	return isMountPoint(name, path)
+}
+
+// resolvePath returns the absolute path of name with symbolic links
+// resolved.
+func resolvePath(name string) (string, error) {
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return "", &os.PathError{Op: "abs", Path: name, Err: err}
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+// isMountPointDev reports whether path is a mount point by comparing its
+// device number with that of its parent, bind mounts of directories of the
+// same file system are not detected.
+func isMountPointDev(name, path string) (bool, error) {
+	fi, err := Stat(path)
+	if err != nil {
+		return false, err
+	}
+	id, ok := FileIDOf(fi)
+	if !ok {
+		return false, &os.PathError{Op: "ismountpoint", Path: name, Err: ErrNotSupported}
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return true, nil
+	}
+	pfi, err := Stat(parent)
+	if err != nil {
+		return false, err
+	}
+	pid, _ := FileIDOf(pfi)
+	return id.Dev != pid.Dev, nil
+}
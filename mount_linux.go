@@ -0,0 +1,27 @@
+package fs
+
+import "os"
+
+const mountInfoPath = "/proc/self/mountinfo"
+
+func mounts() ([]*MountInfo, error) {
+	f, err := os.Open(mountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMountInfo(f)
+}
+
+func isMountPoint(name, path string) (bool, error) {
+	if _, err := Stat(path); err != nil {
+		return false, err
+	}
+	mounts, err := mounts()
+	if err != nil {
+		// /proc is not mounted.
+		return isMountPointDev(name, path)
+	}
+	m := mountOf(mounts, path)
+	return m != nil && m.MountPoint == path, nil
+}
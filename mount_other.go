@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+func mounts() ([]*MountInfo, error) {
+	return nil, ErrNotSupported
+}
+
+func isMountPoint(name, path string) (bool, error) {
+	return isMountPointDev(name, path)
+}
@@ -0,0 +1,185 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func readMountInfo(t *testing.T, name string) []*MountInfo {
+	f, err := os.Open(filepath.Join("testdata", "mountinfo", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	mounts, err := ParseMountInfo(f)
+	if err != nil {
+		t.Fatalf("ParseMountInfo(%s): %v", name, err)
+	}
+	return mounts
+}
+
+func TestParseMountInfo(t *testing.T) {
+	mounts := readMountInfo(t, "basic.txt")
+	if len(mounts) != 9 {
+		t.Fatalf("ParseMountInfo: got %d mounts; want 9", len(mounts))
+	}
+	want := &MountInfo{
+		ID:           25,
+		ParentID:     22,
+		Major:        0,
+		Minor:        5,
+		Root:         "/",
+		MountPoint:   "/dev",
+		Options:      "rw,nosuid,relatime",
+		Optional:     []string{"shared:3"},
+		FSType:       "devtmpfs",
+		Source:       "udev",
+		SuperOptions: "rw,size=8123456k,nr_inodes=2030864,mode=755",
+		Flags:        MountNoSuid | MountRelatime,
+	}
+	if !reflect.DeepEqual(mounts[3], want) {
+		t.Errorf("ParseMountInfo:\ngot:  %+v\nwant: %+v", mounts[3], want)
+	}
+	if m := mounts[0]; m.Major != 8 || m.Minor != 1 || m.ParentID != 1 {
+		t.Errorf("ParseMountInfo: root mount %+v", m)
+	}
+	if m := mounts[8]; m.Optional != nil || m.Flags != MountReadOnly|MountNoAtime {
+		t.Errorf("ParseMountInfo: got optional fields %q and flags %s", m.Optional, m.Flags)
+	}
+}
+
+func TestParseMountInfoEscaped(t *testing.T) {
+	mounts := readMountInfo(t, "escaped.txt")
+	var points []string
+	for _, m := range mounts {
+		points = append(points, m.MountPoint)
+	}
+	want := []string{"/", "/mnt/with space", "/mnt/tab\tand\\backslash", "/mnt/new\nline"}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("mount points = %q; want %q", points, want)
+	}
+	if m := mounts[1]; m.Source != "user@host:/remote dir" || m.FSType != "fuse.sshfs" {
+		t.Errorf("got source %q and type %q", m.Source, m.FSType)
+	}
+}
+
+func TestParseMountInfoBind(t *testing.T) {
+	mounts := readMountInfo(t, "bind.txt")
+	if m := mounts[1]; m.Root != "/srv/build" || m.MountPoint != "/build" {
+		t.Errorf("bind mount: got root %q mount point %q", m.Root, m.MountPoint)
+	}
+	want := []string{"shared:1", "master:7", "propagate_from:3"}
+	if m := mounts[2]; !reflect.DeepEqual(m.Optional, want) || m.Flags != MountReadOnly|MountRelatime {
+		t.Errorf("got optional fields %q and flags %s; want %q and ro,relatime", m.Optional, m.Flags, want)
+	}
+	if m := mounts[6]; m.Source != "none" || m.SuperOptions != "" {
+		t.Errorf("got source %q and super options %q", m.Source, m.SuperOptions)
+	}
+}
+
+func TestParseMountInfoInvalid(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "mountinfo", "invalid.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := ParseMountInfo(f); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ParseMountInfo: got %v; want error for line 2", err)
+	}
+	for _, line := range []string{
+		"x 22 8:1 / / rw - ext4 /dev/sda1 rw",
+		"22 x 8:1 / / rw - ext4 /dev/sda1 rw",
+		"22 1 8 / / rw - ext4 /dev/sda1 rw",
+		"22 1 8:x / / rw - ext4 /dev/sda1 rw",
+		"22 1 8:1 / / rw - ext4",
+	} {
+		if _, err := ParseMountInfo(strings.NewReader(line)); err == nil {
+			t.Errorf("ParseMountInfo(%q): expected error", line)
+		}
+	}
+}
+
+func TestMountOfTable(t *testing.T) {
+	basic := readMountInfo(t, "basic.txt")
+	bind := readMountInfo(t, "bind.txt")
+	tests := []struct {
+		mounts []*MountInfo
+		path   string
+		id     int
+	}{
+		{basic, "/", 22},
+		{basic, "/etc/passwd", 22},
+		{basic, "/dev", 25},
+		{basic, "/dev/pts/0", 26},
+		{basic, "/devices", 22},
+		{basic, "/home/user/file", 28},
+		{basic, "/mnt/data", 30},
+		{basic, "/mnt", 22},
+		{bind, "/build/out", 60},
+		{bind, "/build/cache/obj", 61},
+		{bind, "/overmount", 63},
+		{bind, "/overmount/file", 63},
+	}
+	for _, test := range tests {
+		m := mountOf(test.mounts, test.path)
+		if m == nil || m.ID != test.id {
+			t.Errorf("mountOf(%q) = %+v; want mount %d", test.path, m, test.id)
+		}
+	}
+}
+
+func TestMountOf(t *testing.T) {
+	dir := newDir("TestMountOf", t)
+	defer RemoveAll(dir)
+
+	m, err := MountOf(dir)
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("MountOf: %v", err)
+	}
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if real != m.MountPoint && !underDir(real, m.MountPoint) && m.MountPoint != "/" {
+		t.Errorf("MountOf(%s) = %s", dir, m.MountPoint)
+	}
+	if _, err := MountOf(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("MountOf of missing file: got %v; want not exist error", err)
+	}
+}
+
+func TestIsMountPoint(t *testing.T) {
+	dir := newDir("TestIsMountPoint", t)
+	defer RemoveAll(dir)
+
+	root := "/"
+	if runtime.GOOS == "windows" {
+		root = filepath.VolumeName(dir) + `\`
+	}
+	ok, err := IsMountPoint(root)
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil || !ok {
+		t.Errorf("IsMountPoint(%s) = %v, %v; want true", root, ok, err)
+	}
+	if ok, err := IsMountPoint(dir); err != nil || ok {
+		t.Errorf("IsMountPoint(%s) = %v, %v; want false", dir, ok, err)
+	}
+	if runtime.GOOS == "linux" {
+		if ok, err := IsMountPoint("/proc"); err == nil && !ok {
+			t.Error("IsMountPoint(/proc) = false; want true")
+		}
+	}
+	if _, err := IsMountPoint(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("IsMountPoint of missing file: got %v; want not exist error", err)
+	}
+}
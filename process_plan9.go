@@ -0,0 +1,12 @@
+package fs
+
+import (
+	"os"
+	"strconv"
+)
+
+// processExists reports whether the process pid exists.
+func processExists(pid int) bool {
+	_, err := os.Stat("/proc/" + strconv.Itoa(pid))
+	return err == nil
+}
@@ -0,0 +1,20 @@
+package fs
+
+import "syscall"
+
+const _STILL_ACTIVE = 259
+
+// processExists reports whether the process pid exists.
+func processExists(pid int) bool {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// The process exists if access to it is denied.
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == _STILL_ACTIVE
+}
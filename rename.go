@@ -0,0 +1,24 @@
+package fs
+
+// RenameNoReplace renames (moves) a file, like Rename, but fails with an
+// error satisfying os.IsExist if newpath already exists. The check and the
+// rename are atomic.
+//
+// It is supported on Linux, using renameat2(2), and on Windows. On other
+// platforms, and on Linux file systems that do not support it, the error
+// wraps ErrNotSupported.
+// If there is an error, it will be of type *LinkError.
+func RenameNoReplace(oldpath, newpath string) error {
+	return renameNoReplace(oldpath, newpath)
+}
+
+// Exchange atomically exchanges the files path1 and path2, which may be of
+// different types, for example a file and a directory. Both must exist.
+//
+// It is only supported on Linux, using renameat2(2). On other platforms,
+// and on Linux file systems that do not support it, the error wraps
+// ErrNotSupported.
+// If there is an error, it will be of type *LinkError.
+func Exchange(path1, path2 string) error {
+	return exchange(path1, path2)
+}
@@ -0,0 +1,64 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// Flags of renameat2(2).
+const (
+	_RENAME_NOREPLACE = 0x1
+	_RENAME_EXCHANGE  = 0x2
+)
+
+func renameat2(op, oldpath, newpath string, flags int) error {
+	p1, err := syscall.BytePtrFromString(oldpath)
+	if err != nil {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+	}
+	p2, err := syscall.BytePtrFromString(newpath)
+	if err != nil {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+	}
+	dirfd := _AT_FDCWD
+	_, _, e := syscall.Syscall6(_SYS_RENAMEAT2, uintptr(dirfd), uintptr(unsafe.Pointer(p1)),
+		uintptr(dirfd), uintptr(unsafe.Pointer(p2)), uintptr(flags), 0)
+	switch {
+	case e == 0:
+		return nil
+	case e == syscall.ENOSYS, e == syscall.EINVAL && !invalidRename(oldpath, newpath):
+		// The kernel does not implement renameat2, or the file system does
+		// not support the flags.
+		err = ErrNotSupported
+	default:
+		err = e
+	}
+	return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+}
+
+// invalidRename reports whether rename(2) fails with EINVAL for the paths,
+// because a directory would be moved into itself or a path ends in "." or
+// "..".
+func invalidRename(oldpath, newpath string) bool {
+	for _, p := range []string{oldpath, newpath} {
+		if base := filepath.Base(p); base == "." || base == ".." {
+			return true
+		}
+	}
+	old, err1 := filepath.Abs(oldpath)
+	new, err2 := filepath.Abs(newpath)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	return underDir(new, old) || underDir(old, new)
+}
+
+func renameNoReplace(oldpath, newpath string) error {
+	return renameat2("renamenoreplace", oldpath, newpath, _RENAME_NOREPLACE)
+}
+
+func exchange(path1, path2 string) error {
+	return renameat2("exchange", path1, path2, _RENAME_EXCHANGE)
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package fs
+
+import "os"
+
+func renameNoReplace(oldpath, newpath string) error {
+	return &os.LinkError{Op: "renamenoreplace", Old: oldpath, New: newpath, Err: ErrNotSupported}
+}
+
+func exchange(path1, path2 string) error {
+	return &os.LinkError{Op: "exchange", Old: path1, New: path2, Err: ErrNotSupported}
+}
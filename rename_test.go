@@ -0,0 +1,110 @@
+package fs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readTestFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRenameNoReplace(t *testing.T) {
+	dir := newDir("TestRenameNoReplace", t)
+	defer RemoveAll(dir)
+	makeTree(t, dir, "from", "to", "dir/", "dir/file", "other/")
+	from := filepath.Join(dir, "from")
+	to := filepath.Join(dir, "to")
+
+	err := RenameNoReplace(from, to)
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+	if !os.IsExist(err) {
+		t.Fatalf("RenameNoReplace to existing file: got %v; want exist error", err)
+	}
+	if _, ok := err.(*os.LinkError); !ok {
+		t.Errorf("RenameNoReplace: error %#v is not a *LinkError", err)
+	}
+	if s := readTestFile(t, to); s != "to" {
+		t.Errorf("destination was overwritten: %q", s)
+	}
+
+	// Directories are not replaced either, even if they are empty.
+	if err := RenameNoReplace(filepath.Join(dir, "dir"), filepath.Join(dir, "other")); !os.IsExist(err) {
+		t.Errorf("RenameNoReplace to existing directory: got %v; want exist error", err)
+	}
+
+	newname := filepath.Join(dir, "new")
+	if err := RenameNoReplace(from, newname); err != nil {
+		t.Fatalf("RenameNoReplace: %v", err)
+	}
+	if s := readTestFile(t, newname); s != "from" {
+		t.Errorf("renamed file contains %q; want %q", s, "from")
+	}
+	if _, err := Lstat(from); !os.IsNotExist(err) {
+		t.Errorf("source still exists: %v", err)
+	}
+
+	if err := RenameNoReplace(filepath.Join(dir, "dir"), filepath.Join(dir, "newdir")); err != nil {
+		t.Fatalf("RenameNoReplace of directory: %v", err)
+	}
+	if s := readTestFile(t, filepath.Join(dir, "newdir", "file")); s != "dir/file" {
+		t.Errorf("renamed directory file contains %q", s)
+	}
+
+	if err := RenameNoReplace(filepath.Join(dir, "missing"), filepath.Join(dir, "x")); !os.IsNotExist(err) {
+		t.Errorf("RenameNoReplace of missing file: got %v; want not exist error", err)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	dir := newDir("TestExchange", t)
+	defer RemoveAll(dir)
+	makeTree(t, dir, "a", "b", "blue/", "blue/file", "green/")
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+
+	err := Exchange(a, b)
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if sa, sb := readTestFile(t, a), readTestFile(t, b); sa != "b" || sb != "a" {
+		t.Errorf("after Exchange: a = %q, b = %q", sa, sb)
+	}
+
+	// Directories, and a directory with a file.
+	blue := filepath.Join(dir, "blue")
+	green := filepath.Join(dir, "green")
+	if err := Exchange(blue, green); err != nil {
+		t.Fatalf("Exchange of directories: %v", err)
+	}
+	if _, err := Lstat(filepath.Join(green, "file")); err != nil {
+		t.Errorf("Exchange of directories: %v", err)
+	}
+	if err := Exchange(a, green); err != nil {
+		t.Fatalf("Exchange of file and directory: %v", err)
+	}
+	if fi, err := Lstat(a); err != nil || !fi.IsDir() {
+		t.Errorf("Exchange of file and directory: %s is not a directory: %v", a, err)
+	}
+
+	err = Exchange(a, filepath.Join(dir, "missing"))
+	if !os.IsNotExist(err) {
+		t.Errorf("Exchange with missing file: got %v; want not exist error", err)
+	}
+	if _, ok := err.(*os.LinkError); !ok {
+		t.Errorf("Exchange: error %#v is not a *LinkError", err)
+	}
+}
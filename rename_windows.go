@@ -0,0 +1,32 @@
+package fs
+
+import "syscall"
+
+func renameNoReplace(oldpath, newpath string) error {
+	from, err := winPath(oldpath)
+	if err != nil {
+		return newLinkError("renamenoreplace", oldpath, newpath, err)
+	}
+	to, err := winPath(newpath)
+	if err != nil {
+		return newLinkError("renamenoreplace", oldpath, newpath, err)
+	}
+	p1, err := syscall.UTF16PtrFromString(from)
+	if err != nil {
+		return newLinkError("renamenoreplace", oldpath, newpath, err)
+	}
+	p2, err := syscall.UTF16PtrFromString(to)
+	if err != nil {
+		return newLinkError("renamenoreplace", oldpath, newpath, err)
+	}
+	// Unlike MoveFileEx with MOVEFILE_REPLACE_EXISTING, which is used by
+	// os.Rename, MoveFile fails if the destination exists.
+	if err := syscall.MoveFile(p1, p2); err != nil {
+		return newLinkError("renamenoreplace", oldpath, newpath, err)
+	}
+	return nil
+}
+
+func exchange(path1, path2 string) error {
+	return newLinkError("exchange", path1, path2, ErrNotSupported)
+}
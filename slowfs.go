@@ -0,0 +1,263 @@
+package fs
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SlowOptions configure a SlowFS.
+type SlowOptions struct {
+	// The latencies are added to each call of the operations.
+	StatLatency     time.Duration // Stat, Lstat, Readlink and Stat of files
+	OpenLatency     time.Duration // Open, Create and OpenFile
+	ReadDirLatency  time.Duration // ReadDir, and ReadDir and Readdirnames of files
+	ReadLatency     time.Duration // Read and ReadAt of files
+	WriteLatency    time.Duration // Write and WriteAt of files
+	SyncLatency     time.Duration // Sync of files
+	MetadataLatency time.Duration // other operations, such as Rename and Remove
+
+	// ReadBandwidth and WriteBandwidth limit the rate, in bytes per
+	// second, at which files are read and written. The bandwidth is
+	// shared by all files of the SlowFS. If zero, it is not limited.
+	ReadBandwidth  int64
+	WriteBandwidth int64
+
+	// Clock is used to wait. If nil, SystemClock is used.
+	Clock Clock
+}
+
+// A SlowFS is an FS that slows down the operations of another FS, to
+// reproduce the behavior of slow disks or network file systems in tests.
+// Used with a fake Clock the delays take no time.
+type SlowFS struct {
+	fsys FS
+	opts SlowOptions
+
+	mu        sync.Mutex
+	readBusy  time.Time // time until which the read bandwidth is in use
+	writeBusy time.Time // time until which the write bandwidth is in use
+}
+
+// NewSlowFS returns a SlowFS performing the operations of fsys. If fsys is
+// nil, SystemFS is used.
+func NewSlowFS(fsys FS, opts *SlowOptions) *SlowFS {
+	if fsys == nil {
+		fsys = SystemFS
+	}
+	s := &SlowFS{fsys: fsys}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Clock == nil {
+		s.opts.Clock = SystemClock
+	}
+	return s
+}
+
+func (s *SlowFS) wait(d time.Duration) {
+	if d > 0 {
+		<-s.opts.Clock.After(d)
+	}
+}
+
+// transfer waits until n bytes were transferred at the bandwidth, after
+// the transfers already in progress, which ended or end at busy.
+func (s *SlowFS) transfer(busy *time.Time, bandwidth int64, n int) {
+	if bandwidth <= 0 || n <= 0 {
+		return
+	}
+	d := time.Duration(float64(n) / float64(bandwidth) * float64(time.Second))
+	s.mu.Lock()
+	now := s.opts.Clock.Now()
+	if busy.Before(now) {
+		*busy = now
+	}
+	*busy = busy.Add(d)
+	d = busy.Sub(now)
+	s.mu.Unlock()
+	s.wait(d)
+}
+
+func (s *SlowFS) Chmod(name string, mode os.FileMode) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Chmod(name, mode)
+}
+
+func (s *SlowFS) Chown(name string, uid, gid int) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Chown(name, uid, gid)
+}
+
+func (s *SlowFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Chtimes(name, atime, mtime)
+}
+
+func (s *SlowFS) Lchmod(name string, mode os.FileMode) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Lchmod(name, mode)
+}
+
+func (s *SlowFS) Lchown(name string, uid, gid int) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Lchown(name, uid, gid)
+}
+
+func (s *SlowFS) Link(oldname, newname string) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Link(oldname, newname)
+}
+
+func (s *SlowFS) Mkdir(name string, perm os.FileMode) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Mkdir(name, perm)
+}
+
+func (s *SlowFS) MkdirAll(path string, perm os.FileMode) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.MkdirAll(path, perm)
+}
+
+func (s *SlowFS) ReadDir(name string) ([]os.DirEntry, error) {
+	s.wait(s.opts.ReadDirLatency)
+	return s.fsys.ReadDir(name)
+}
+
+func (s *SlowFS) Readlink(name string) (string, error) {
+	s.wait(s.opts.StatLatency)
+	return s.fsys.Readlink(name)
+}
+
+func (s *SlowFS) Remove(name string) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Remove(name)
+}
+
+func (s *SlowFS) RemoveAll(path string) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.RemoveAll(path)
+}
+
+func (s *SlowFS) Rename(oldpath, newpath string) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Rename(oldpath, newpath)
+}
+
+func (s *SlowFS) Symlink(oldname, newname string) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Symlink(oldname, newname)
+}
+
+func (s *SlowFS) Truncate(name string, size int64) error {
+	s.wait(s.opts.MetadataLatency)
+	return s.fsys.Truncate(name, size)
+}
+
+func (s *SlowFS) Create(name string) (File, error) {
+	return s.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (s *SlowFS) Open(name string) (File, error) {
+	return s.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (s *SlowFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	s.wait(s.opts.OpenLatency)
+	f, err := s.fsys.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &slowFile{File: f, fs: s}, nil
+}
+
+func (s *SlowFS) ReadFile(name string) ([]byte, error) {
+	f, err := s.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func (s *SlowFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	f, err := s.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *SlowFS) Lstat(name string) (os.FileInfo, error) {
+	s.wait(s.opts.StatLatency)
+	return s.fsys.Lstat(name)
+}
+
+func (s *SlowFS) Stat(name string) (os.FileInfo, error) {
+	s.wait(s.opts.StatLatency)
+	return s.fsys.Stat(name)
+}
+
+// A slowFile is a file opened by a SlowFS.
+type slowFile struct {
+	File
+	fs *SlowFS
+}
+
+func (f *slowFile) Read(b []byte) (int, error) {
+	f.fs.wait(f.fs.opts.ReadLatency)
+	n, err := f.File.Read(b)
+	f.fs.transfer(&f.fs.readBusy, f.fs.opts.ReadBandwidth, n)
+	return n, err
+}
+
+func (f *slowFile) ReadAt(b []byte, off int64) (int, error) {
+	f.fs.wait(f.fs.opts.ReadLatency)
+	n, err := f.File.ReadAt(b, off)
+	f.fs.transfer(&f.fs.readBusy, f.fs.opts.ReadBandwidth, n)
+	return n, err
+}
+
+func (f *slowFile) Write(b []byte) (int, error) {
+	f.fs.wait(f.fs.opts.WriteLatency)
+	n, err := f.File.Write(b)
+	f.fs.transfer(&f.fs.writeBusy, f.fs.opts.WriteBandwidth, n)
+	return n, err
+}
+
+func (f *slowFile) WriteAt(b []byte, off int64) (int, error) {
+	f.fs.wait(f.fs.opts.WriteLatency)
+	n, err := f.File.WriteAt(b, off)
+	f.fs.transfer(&f.fs.writeBusy, f.fs.opts.WriteBandwidth, n)
+	return n, err
+}
+
+func (f *slowFile) Stat() (os.FileInfo, error) {
+	f.fs.wait(f.fs.opts.StatLatency)
+	return f.File.Stat()
+}
+
+func (f *slowFile) Sync() error {
+	f.fs.wait(f.fs.opts.SyncLatency)
+	return f.File.Sync()
+}
+
+func (f *slowFile) Truncate(size int64) error {
+	f.fs.wait(f.fs.opts.MetadataLatency)
+	return f.File.Truncate(size)
+}
+
+func (f *slowFile) ReadDir(n int) ([]os.DirEntry, error) {
+	f.fs.wait(f.fs.opts.ReadDirLatency)
+	return f.File.ReadDir(n)
+}
+
+func (f *slowFile) Readdirnames(n int) ([]string, error) {
+	f.fs.wait(f.fs.opts.ReadDirLatency)
+	return f.File.Readdirnames(n)
+}
@@ -0,0 +1,118 @@
+package fs
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var _ FS = (*SlowFS)(nil)
+
+// waitClock is a Clock recording the durations waited for, which elapse
+// immediately if advance is set, and otherwise do not elapse at all.
+type waitClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	advance bool
+	waits   []time.Duration
+}
+
+func (c *waitClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *waitClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	if c.advance {
+		c.now = c.now.Add(d)
+	}
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// reset returns the durations waited for since the last call.
+func (c *waitClock) reset() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	waits := c.waits
+	c.waits = nil
+	return waits
+}
+
+func TestSlowFS(t *testing.T) {
+	dir := TempTree(t, map[string]string{"a/file": "0123456789"})
+	clock := &waitClock{advance: true}
+	s := NewSlowFS(nil, &SlowOptions{
+		StatLatency:     1 * time.Millisecond,
+		OpenLatency:     2 * time.Millisecond,
+		ReadDirLatency:  3 * time.Millisecond,
+		WriteLatency:    4 * time.Millisecond,
+		MetadataLatency: 5 * time.Millisecond,
+		ReadBandwidth:   10,
+		WriteBandwidth:  5,
+		Clock:           clock,
+	})
+	name := filepath.Join(dir, "a", "file")
+	const ms = time.Millisecond
+
+	tests := []struct {
+		name  string
+		fn    func() error
+		waits []time.Duration
+	}{
+		{"Stat", func() error {
+			_, err := s.Stat(name)
+			return err
+		}, []time.Duration{1 * ms}},
+		{"ReadDir", func() error {
+			_, err := s.ReadDir(filepath.Join(dir, "a"))
+			return err
+		}, []time.Duration{3 * ms}},
+		{"Rename", func() error {
+			return s.Rename(name, name)
+		}, []time.Duration{5 * ms}},
+		{"ReadFile", func() error {
+			data, err := s.ReadFile(name)
+			if err == nil && string(data) != "0123456789" {
+				t.Errorf("ReadFile = %q", data)
+			}
+			return err
+		}, []time.Duration{2 * ms, time.Second}},
+		{"WriteFile", func() error {
+			return s.WriteFile(name, []byte("01234"), 0644)
+		}, []time.Duration{2 * ms, 4 * ms, time.Second}},
+	}
+	for _, test := range tests {
+		clock.reset()
+		if err := test.fn(); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := clock.reset(); !reflect.DeepEqual(got, test.waits) {
+			t.Errorf("%s: waited %v, want %v", test.name, got, test.waits)
+		}
+	}
+}
+
+func TestSlowFSSharedBandwidth(t *testing.T) {
+	dir := TempTree(t, map[string]string{"a": "0123456789", "b": "0123456789"})
+	// The time does not pass, as if the reads were concurrent.
+	clock := &waitClock{}
+	s := NewSlowFS(nil, &SlowOptions{ReadBandwidth: 10, Clock: clock})
+	for _, name := range []string{"a", "b"} {
+		if _, err := s.ReadFile(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []time.Duration{time.Second, 2 * time.Second}
+	if got := clock.reset(); !reflect.DeepEqual(got, want) {
+		t.Errorf("waited %v, want %v", got, want)
+	}
+}
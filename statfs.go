@@ -0,0 +1,52 @@
+package fs
+
+import "strings"
+
+// MountFlags describes the options a file system is mounted with.
+type MountFlags uint32
+
+// These are the mount flags reported by StatFS.
+const (
+	MountReadOnly MountFlags = 1 << iota // mounted read-only
+	MountNoSuid                          // set-user-ID and set-group-ID bits are ignored
+	MountNoDev                           // device files can not be accessed
+	MountNoExec                          // programs can not be executed
+	MountSync                            // writes are synchronous
+	MountNoAtime                         // access times are not updated
+	MountRelatime                        // access times are updated relative to modification times
+)
+
+var mountFlagNames = []string{"ro", "nosuid", "nodev", "noexec", "sync", "noatime", "relatime"}
+
+// String returns the flags as a comma separated list of mount options,
+// e.g. "ro,nosuid".
+func (f MountFlags) String() string {
+	var names []string
+	for i, name := range mountFlagNames {
+		if f&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// FSStat describes a mounted file system.
+type FSStat struct {
+	Type      string     // file system type, e.g. "ext4", "tmpfs" or "NTFS"
+	BlockSize int64      // fundamental block size
+	Total     uint64     // size in bytes
+	Free      uint64     // free bytes
+	Avail     uint64     // free bytes available to unprivileged users
+	Files     uint64     // total number of inodes, zero if not reported
+	FilesFree uint64     // free inodes
+	NameMax   int        // maximum length of file names, zero if unknown
+	Flags     MountFlags // mount options
+}
+
+// StatFS returns statistics about the file system containing the named
+// file. File system types that are not known are returned as their
+// hexadecimal magic number, e.g. "0x12345678".
+// If there is an error, it will be of type *PathError.
+func StatFS(name string) (*FSStat, error) {
+	return statFS(name)
+}
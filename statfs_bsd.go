@@ -0,0 +1,65 @@
+//go:build darwin || freebsd
+// +build darwin freebsd
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// Flags of statfs(2) shared by darwin and freebsd.
+const (
+	_MNT_RDONLY      = 0x1
+	_MNT_SYNCHRONOUS = 0x2
+	_MNT_NOEXEC      = 0x4
+	_MNT_NOSUID      = 0x8
+	_MNT_NOATIME     = 0x10000000
+)
+
+type statfsFlag struct {
+	mnt  uint64
+	flag MountFlags
+}
+
+func statFS(name string) (*FSStat, error) {
+	var st syscall.Statfs_t
+	err := ignoringEINTR(func() error {
+		return syscall.Statfs(name, &st)
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "statfs", Path: name, Err: err}
+	}
+	bsize := uint64(st.Bsize)
+	fs := &FSStat{
+		Type:      fsTypeName(st.Fstypename[:]),
+		BlockSize: int64(bsize),
+		Total:     st.Blocks * bsize,
+		Free:      st.Bfree * bsize,
+		Avail:     uint64(st.Bavail) * bsize,
+		Files:     st.Files,
+		FilesFree: uint64(st.Ffree),
+		NameMax:   nameMax(&st),
+	}
+	// Negative counts are reported for reserved space that is in use.
+	if int64(st.Bavail) < 0 {
+		fs.Avail = 0
+	}
+	for _, f := range statfsFlags {
+		if uint64(st.Flags)&f.mnt != 0 {
+			fs.Flags |= f.flag
+		}
+	}
+	return fs, nil
+}
+
+func fsTypeName(b []int8) string {
+	name := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return string(name)
+}
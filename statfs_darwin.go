@@ -0,0 +1,20 @@
+package fs
+
+import "syscall"
+
+// The maximum length of file names is not reported by statfs(2) on darwin,
+// NAME_MAX is assumed.
+func nameMax(st *syscall.Statfs_t) int {
+	return 255
+}
+
+const _MNT_NODEV = 0x10
+
+var statfsFlags = []statfsFlag{
+	{_MNT_RDONLY, MountReadOnly},
+	{_MNT_NOSUID, MountNoSuid},
+	{_MNT_NODEV, MountNoDev},
+	{_MNT_NOEXEC, MountNoExec},
+	{_MNT_SYNCHRONOUS, MountSync},
+	{_MNT_NOATIME, MountNoAtime},
+}
@@ -0,0 +1,16 @@
+package fs
+
+import "syscall"
+
+func nameMax(st *syscall.Statfs_t) int {
+	return int(st.Namemax)
+}
+
+// FreeBSD does not report nodev, its flag 0x10 is MNT_NFS4ACLS.
+var statfsFlags = []statfsFlag{
+	{_MNT_RDONLY, MountReadOnly},
+	{_MNT_NOSUID, MountNoSuid},
+	{_MNT_NOEXEC, MountNoExec},
+	{_MNT_SYNCHRONOUS, MountSync},
+	{_MNT_NOATIME, MountNoAtime},
+}
@@ -0,0 +1,120 @@
+package fs
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// Flags of statfs(2).
+const (
+	_ST_RDONLY      = 0x1
+	_ST_NOSUID      = 0x2
+	_ST_NODEV       = 0x4
+	_ST_NOEXEC      = 0x8
+	_ST_SYNCHRONOUS = 0x10
+	_ST_VALID       = 0x20
+	_ST_NOATIME     = 0x400
+	_ST_RELATIME    = 0x1000
+)
+
+var statfsFlags = []struct {
+	st   int64
+	flag MountFlags
+}{
+	{_ST_RDONLY, MountReadOnly},
+	{_ST_NOSUID, MountNoSuid},
+	{_ST_NODEV, MountNoDev},
+	{_ST_NOEXEC, MountNoExec},
+	{_ST_SYNCHRONOUS, MountSync},
+	{_ST_NOATIME, MountNoAtime},
+	{_ST_RELATIME, MountRelatime},
+}
+
+// fsTypes maps the magic numbers of statfs(2) to file system names, see
+// linux/magic.h.
+var fsTypes = map[uint32]string{
+	0x00000187: "autofs",
+	0x00c36400: "ceph",
+	0x01021994: "tmpfs",
+	0x01021997: "9p",
+	0x0027e0eb: "cgroup",
+	0x00001cd1: "devpts",
+	0x00009660: "iso9660",
+	0x00004d44: "vfat",
+	0x00006969: "nfs",
+	0x00009fa0: "proc",
+	0x0000ef53: "ext4", // also ext2 and ext3, which share the magic number
+	0x0000f15f: "ecryptfs",
+	0x15013346: "udf",
+	0x19800202: "mqueue",
+	0x2011bab0: "exfat",
+	0x2fc12fc1: "zfs",
+	0x3153464a: "jfs",
+	0x42494e4d: "binfmt_misc",
+	0x52654973: "reiserfs",
+	0x5346544e: "ntfs",
+	0x58465342: "xfs",
+	0x61756673: "aufs",
+	0x62656570: "configfs",
+	0x62656572: "sysfs",
+	0x63677270: "cgroup2",
+	0x64626720: "debugfs",
+	0x6165676c: "pstore",
+	0x65735546: "fuse",
+	0x73636673: "securityfs",
+	0x73717368: "squashfs",
+	0x74726163: "tracefs",
+	0x794c7630: "overlayfs",
+	0x858458f6: "ramfs",
+	0x9123683e: "btrfs",
+	0x958458f6: "hugetlbfs",
+	0xcafe4a11: "bpf",
+	0xca451a4e: "bcachefs",
+	0xde5e81e4: "efivarfs",
+	0xe0f5e1e2: "erofs",
+	0xf2f52010: "f2fs",
+	0xf97cff8c: "selinuxfs",
+	0xfe534d42: "smb2",
+	0xff534d42: "cifs",
+}
+
+func fsTypeName(magic uint32) string {
+	if name, ok := fsTypes[magic]; ok {
+		return name
+	}
+	return "0x" + strconv.FormatUint(uint64(magic), 16)
+}
+
+func statFS(name string) (*FSStat, error) {
+	var st syscall.Statfs_t
+	err := ignoringEINTR(func() error {
+		return syscall.Statfs(name, &st)
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "statfs", Path: name, Err: err}
+	}
+	// Block counts are in units of the fragment size.
+	bsize := uint64(st.Frsize)
+	if bsize == 0 {
+		bsize = uint64(st.Bsize)
+	}
+	fs := &FSStat{
+		Type:      fsTypeName(uint32(st.Type)),
+		BlockSize: int64(bsize),
+		Total:     st.Blocks * bsize,
+		Free:      st.Bfree * bsize,
+		Avail:     st.Bavail * bsize,
+		Files:     st.Files,
+		FilesFree: st.Ffree,
+		NameMax:   int(st.Namelen),
+	}
+	if flags := int64(st.Flags); flags&_ST_VALID != 0 {
+		for _, f := range statfsFlags {
+			if flags&f.st != 0 {
+				fs.Flags |= f.flag
+			}
+		}
+	}
+	return fs, nil
+}
@@ -0,0 +1,16 @@
+package fs
+
+import "testing"
+
+func TestStatFSType(t *testing.T) {
+	fs, err := StatFS("/proc/self")
+	if err != nil {
+		t.Skip(err)
+	}
+	if fs.Type != "proc" {
+		t.Errorf("StatFS(/proc/self).Type = %q; want %q", fs.Type, "proc")
+	}
+	if fsTypeName(0x1234) != "0x1234" {
+		t.Errorf("fsTypeName(0x1234) = %q", fsTypeName(0x1234))
+	}
+}
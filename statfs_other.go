@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package fs
+
+import "os"
+
+func statFS(name string) (*FSStat, error) {
+	return nil, &os.PathError{Op: "statfs", Path: name, Err: ErrNotSupported}
+}
@@ -0,0 +1,57 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatFS(t *testing.T) {
+	dir := newDir("TestStatFS", t)
+	defer RemoveAll(dir)
+
+	fs, err := StatFS(dir)
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("StatFS: %v", err)
+	}
+	if fs.Type == "" {
+		t.Error("StatFS: empty file system type")
+	}
+	if fs.BlockSize <= 0 {
+		t.Errorf("StatFS: invalid block size %d", fs.BlockSize)
+	}
+	if fs.Total == 0 || fs.Free > fs.Total || fs.Avail > fs.Total {
+		t.Errorf("StatFS: invalid sizes: total %d free %d avail %d", fs.Total, fs.Free, fs.Avail)
+	}
+	if fs.Flags&MountReadOnly != 0 {
+		t.Errorf("StatFS: writable directory %s reported as read-only: %s", dir, fs.Flags)
+	}
+
+	_, err = StatFS(filepath.Join(dir, "missing"))
+	if perr, ok := err.(*os.PathError); !ok || !os.IsNotExist(err) {
+		t.Errorf("StatFS of missing file: got %#v; want not exist *PathError", err)
+	} else if perr.Path != filepath.Join(dir, "missing") {
+		t.Errorf("StatFS: error path = %q", perr.Path)
+	}
+}
+
+func TestMountFlagsString(t *testing.T) {
+	tests := []struct {
+		flags MountFlags
+		want  string
+	}{
+		{0, ""},
+		{MountReadOnly, "ro"},
+		{MountReadOnly | MountNoSuid | MountNoDev, "ro,nosuid,nodev"},
+		{MountNoExec | MountRelatime, "noexec,relatime"},
+	}
+	for _, test := range tests {
+		if s := test.flags.String(); s != test.want {
+			t.Errorf("MountFlags(%#x).String() = %q; want %q", uint32(test.flags), s, test.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package fs
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procGetDiskFreeSpaceExW   = modkernel32.NewProc("GetDiskFreeSpaceExW")
+	procGetDiskFreeSpaceW     = modkernel32.NewProc("GetDiskFreeSpaceW")
+	procGetVolumePathNameW    = modkernel32.NewProc("GetVolumePathNameW")
+	procGetVolumeInformationW = modkernel32.NewProc("GetVolumeInformationW")
+)
+
+const _FILE_READ_ONLY_VOLUME = 0x00080000
+
+func statFS(name string) (*FSStat, error) {
+	p, err := winPath(name)
+	if err != nil {
+		return nil, newPathError("statfs", name, err)
+	}
+	path, err := syscall.UTF16PtrFromString(p)
+	if err != nil {
+		return nil, newPathError("statfs", name, err)
+	}
+
+	// The volume functions require the root of the volume, which may be a
+	// mounted folder.
+	root := make([]uint16, syscall.MAX_LONG_PATH)
+	r, _, e := procGetVolumePathNameW.Call(uintptr(unsafe.Pointer(path)),
+		uintptr(unsafe.Pointer(&root[0])), uintptr(len(root)))
+	if r == 0 {
+		return nil, newPathError("statfs", name, e)
+	}
+
+	var avail, total, free uint64
+	r, _, e = procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(&root[0])),
+		uintptr(unsafe.Pointer(&avail)), uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)))
+	if r == 0 {
+		return nil, newPathError("statfs", name, e)
+	}
+
+	var sectorsPerCluster, bytesPerSector, freeClusters, clusters uint32
+	r, _, e = procGetDiskFreeSpaceW.Call(uintptr(unsafe.Pointer(&root[0])),
+		uintptr(unsafe.Pointer(&sectorsPerCluster)), uintptr(unsafe.Pointer(&bytesPerSector)),
+		uintptr(unsafe.Pointer(&freeClusters)), uintptr(unsafe.Pointer(&clusters)))
+	if r == 0 {
+		return nil, newPathError("statfs", name, e)
+	}
+
+	var maxComponentLength, flags uint32
+	fsName := make([]uint16, syscall.MAX_PATH+1)
+	r, _, e = procGetVolumeInformationW.Call(uintptr(unsafe.Pointer(&root[0])), 0, 0, 0,
+		uintptr(unsafe.Pointer(&maxComponentLength)), uintptr(unsafe.Pointer(&flags)),
+		uintptr(unsafe.Pointer(&fsName[0])), uintptr(len(fsName)))
+	if r == 0 {
+		return nil, newPathError("statfs", name, e)
+	}
+
+	fs := &FSStat{
+		Type:      syscall.UTF16ToString(fsName),
+		BlockSize: int64(sectorsPerCluster) * int64(bytesPerSector),
+		Total:     total,
+		Free:      free,
+		Avail:     avail,
+		NameMax:   int(maxComponentLength),
+	}
+	if flags&_FILE_READ_ONLY_VOLUME != 0 {
+		fs.Flags |= MountReadOnly
+	}
+	return fs, nil
+}
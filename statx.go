@@ -0,0 +1,98 @@
+package fs
+
+import (
+	"os"
+	"time"
+)
+
+// StatxMask selects the fields requested from, and reported by, Statx.
+type StatxMask uint32
+
+// These are the fields of a StatxInfo, the values match STATX_* of
+// statx(2).
+const (
+	StatxType       StatxMask = 0x1
+	StatxMode       StatxMask = 0x2
+	StatxNlink      StatxMask = 0x4
+	StatxUID        StatxMask = 0x8
+	StatxGID        StatxMask = 0x10
+	StatxAtime      StatxMask = 0x20
+	StatxMtime      StatxMask = 0x40
+	StatxCtime      StatxMask = 0x80
+	StatxIno        StatxMask = 0x100
+	StatxSize       StatxMask = 0x200
+	StatxBlocks     StatxMask = 0x400
+	StatxBasicStats StatxMask = 0x7ff // the fields returned by Stat
+	StatxBtime      StatxMask = 0x800
+	StatxMntID      StatxMask = 0x1000
+	StatxAll        StatxMask = StatxBasicStats | StatxBtime | StatxMntID
+)
+
+// StatxAttr is a set of file attributes reported by Statx, the values
+// match STATX_ATTR_* of statx(2).
+type StatxAttr uint64
+
+// These are the file attributes reported by Statx.
+const (
+	StatxAttrCompressed StatxAttr = 0x4      // compressed by the file system
+	StatxAttrImmutable  StatxAttr = 0x10     // can not be modified
+	StatxAttrAppend     StatxAttr = 0x20     // can only be opened for appending
+	StatxAttrNodump     StatxAttr = 0x40     // not a candidate for backup
+	StatxAttrEncrypted  StatxAttr = 0x800    // requires a key to be decrypted
+	StatxAttrAutomount  StatxAttr = 0x1000   // automount trigger
+	StatxAttrMountRoot  StatxAttr = 0x2000   // root of a mount
+	StatxAttrVerity     StatxAttr = 0x100000 // protected by fs-verity
+	StatxAttrDAX        StatxAttr = 0x200000 // direct access to persistent memory
+)
+
+// StatxInfo describes a file, as returned by Statx. Only the fields
+// selected by Mask are valid.
+type StatxInfo struct {
+	Mask           StatxMask // fields that are valid
+	Attributes     StatxAttr // attributes of the file
+	AttributesMask StatxAttr // attributes supported by the file system
+	Mode           os.FileMode
+	Nlink          uint32
+	UID, GID       uint32
+	Ino            uint64
+	Size           int64
+	Blocks         uint64 // number of 512 byte blocks allocated
+	BlockSize      uint32 // preferred block size for I/O
+	Atime          time.Time
+	Btime          time.Time // creation time
+	Ctime          time.Time
+	Mtime          time.Time
+	DevMajor       uint32 // device containing the file
+	DevMinor       uint32
+	RdevMajor      uint32 // device represented by the file, if it is a device file
+	RdevMinor      uint32
+	MntID          uint64 // ID of the mount containing the file, see MountInfo
+}
+
+// Statx returns extended information about the named file, mask selects
+// the fields to return although the file system may return more or fewer
+// fields. If statx(2) is not supported by the kernel, the information is
+// obtained with stat(2) and only includes the basic fields. Statx is only
+// supported on Linux, on other platforms the error wraps ErrNotSupported.
+// If there is an error, it will be of type *PathError.
+func Statx(name string, mask StatxMask) (*StatxInfo, error) {
+	return statx(name, mask, true)
+}
+
+// Lstatx is like Statx, but if the file is a symbolic link the returned
+// information describes the link itself.
+func Lstatx(name string, mask StatxMask) (*StatxInfo, error) {
+	return statx(name, mask, false)
+}
+
+// Fstatx returns extended information about f.
+func Fstatx(f *os.File, mask StatxMask) (*StatxInfo, error) {
+	return fstatx(f, mask)
+}
+
+// FileStatx returns the information of a FileInfo returned by Stat, Lstat
+// or the Stat method of a File as a StatxInfo. The information only
+// includes the basic fields, it reports false if it is not available.
+func FileStatx(fi os.FileInfo) (*StatxInfo, bool) {
+	return fileStatx(fi)
+}
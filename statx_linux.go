@@ -0,0 +1,201 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// rawStatx is struct statx of statx(2).
+type rawStatx struct {
+	Mask           uint32
+	Blksize        uint32
+	Attributes     uint64
+	Nlink          uint32
+	UID            uint32
+	GID            uint32
+	Mode           uint16
+	_              uint16
+	Ino            uint64
+	Size           uint64
+	Blocks         uint64
+	AttributesMask uint64
+	Atime          statxTimestamp
+	Btime          statxTimestamp
+	Ctime          statxTimestamp
+	Mtime          statxTimestamp
+	RdevMajor      uint32
+	RdevMinor      uint32
+	DevMajor       uint32
+	DevMinor       uint32
+	MntID          uint64
+	_              [13]uint64
+}
+
+type statxTimestamp struct {
+	Sec  int64
+	Nsec uint32
+	_    int32
+}
+
+func (t statxTimestamp) time() time.Time {
+	return time.Unix(t.Sec, int64(t.Nsec))
+}
+
+func rawStatxCall(dirfd int, path *byte, flags int, mask StatxMask, st *rawStatx) error {
+	_, _, e := syscall.Syscall6(_SYS_STATX, uintptr(dirfd), uintptr(unsafe.Pointer(path)),
+		uintptr(flags), uintptr(mask), uintptr(unsafe.Pointer(st)), 0)
+	return errnoErr(e)
+}
+
+// statxUnsupported reports whether err is returned by kernels that do not
+// implement statx, or sandboxes that do not allow it.
+func statxUnsupported(err error) bool {
+	return err == syscall.ENOSYS || err == syscall.EPERM
+}
+
+func statx(name string, mask StatxMask, follow bool) (*StatxInfo, error) {
+	op := "statx"
+	flags := 0
+	if !follow {
+		op = "lstatx"
+		flags = _AT_SYMLINK_NOFOLLOW
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: name, Err: err}
+	}
+	var st rawStatx
+	err = ignoringEINTR(func() error {
+		return rawStatxCall(_AT_FDCWD, p, flags, mask, &st)
+	})
+	if err == nil {
+		return st.info(), nil
+	}
+	if !statxUnsupported(err) {
+		return nil, &os.PathError{Op: op, Path: name, Err: err}
+	}
+	var sst syscall.Stat_t
+	if follow {
+		err = syscall.Stat(name, &sst)
+	} else {
+		err = syscall.Lstat(name, &sst)
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return statxFromStat(&sst), nil
+}
+
+func fstatx(f *os.File, mask StatxMask) (*StatxInfo, error) {
+	var info *StatxInfo
+	err := controlFd(f, func(fd uintptr) error {
+		var st rawStatx
+		empty := []byte{0}
+		err := ignoringEINTR(func() error {
+			return rawStatxCall(int(fd), &empty[0], _AT_EMPTY_PATH, mask, &st)
+		})
+		if err == nil {
+			info = st.info()
+			return nil
+		}
+		if !statxUnsupported(err) {
+			return err
+		}
+		var sst syscall.Stat_t
+		if err := syscall.Fstat(int(fd), &sst); err != nil {
+			return err
+		}
+		info = statxFromStat(&sst)
+		return nil
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "fstatx", Path: f.Name(), Err: err}
+	}
+	return info, nil
+}
+
+func (st *rawStatx) info() *StatxInfo {
+	return &StatxInfo{
+		Mask:           StatxMask(st.Mask),
+		Attributes:     StatxAttr(st.Attributes),
+		AttributesMask: StatxAttr(st.AttributesMask),
+		Mode:           unixMode(uint32(st.Mode)),
+		Nlink:          st.Nlink,
+		UID:            st.UID,
+		GID:            st.GID,
+		Ino:            st.Ino,
+		Size:           int64(st.Size),
+		Blocks:         st.Blocks,
+		BlockSize:      st.Blksize,
+		Atime:          st.Atime.time(),
+		Btime:          st.Btime.time(),
+		Ctime:          st.Ctime.time(),
+		Mtime:          st.Mtime.time(),
+		DevMajor:       st.DevMajor,
+		DevMinor:       st.DevMinor,
+		RdevMajor:      st.RdevMajor,
+		RdevMinor:      st.RdevMinor,
+		MntID:          st.MntID,
+	}
+}
+
+func statxFromStat(st *syscall.Stat_t) *StatxInfo {
+	dev, rdev := uint64(st.Dev), uint64(st.Rdev)
+	return &StatxInfo{
+		Mask:      StatxBasicStats,
+		Mode:      unixMode(st.Mode),
+		Nlink:     uint32(st.Nlink),
+		UID:       st.Uid,
+		GID:       st.Gid,
+		Ino:       st.Ino,
+		Size:      st.Size,
+		Blocks:    uint64(st.Blocks),
+		BlockSize: uint32(st.Blksize),
+		Atime:     time.Unix(st.Atim.Unix()),
+		Ctime:     time.Unix(st.Ctim.Unix()),
+		Mtime:     time.Unix(st.Mtim.Unix()),
+		DevMajor:  devMajor(dev),
+		DevMinor:  devMinor(dev),
+		RdevMajor: devMajor(rdev),
+		RdevMinor: devMinor(rdev),
+	}
+}
+
+func fileStatx(fi os.FileInfo) (*StatxInfo, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, false
+	}
+	return statxFromStat(st), true
+}
+
+// unixMode converts the st_mode of a file to an os.FileMode.
+func unixMode(m uint32) os.FileMode {
+	mode := os.FileMode(m & 0777)
+	switch m & syscall.S_IFMT {
+	case syscall.S_IFBLK:
+		mode |= os.ModeDevice
+	case syscall.S_IFCHR:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case syscall.S_IFDIR:
+		mode |= os.ModeDir
+	case syscall.S_IFIFO:
+		mode |= os.ModeNamedPipe
+	case syscall.S_IFLNK:
+		mode |= os.ModeSymlink
+	case syscall.S_IFSOCK:
+		mode |= os.ModeSocket
+	}
+	if m&syscall.S_ISGID != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&syscall.S_ISUID != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&syscall.S_ISVTX != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
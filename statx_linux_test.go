@@ -0,0 +1,108 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestStatxSize(t *testing.T) {
+	if n := unsafe.Sizeof(rawStatx{}); n != 256 {
+		t.Fatalf("sizeof(rawStatx) = %d; want 256", n)
+	}
+}
+
+func TestStatx(t *testing.T) {
+	dir := newDir("TestStatx", t)
+	defer RemoveAll(dir)
+	name := filepath.Join(dir, "file")
+	if err := os.WriteFile(name, []byte("hello"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := Symlink(name, link); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := Statx(link, StatxAll)
+	if err != nil {
+		t.Fatalf("Statx: %v", err)
+	}
+	if st.Mask&StatxBasicStats != StatxBasicStats {
+		t.Errorf("Statx: mask %#x does not include the basic fields", st.Mask)
+	}
+	fi, err := Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, ok := FileStatx(fi)
+	if !ok {
+		t.Fatal("FileStatx: information not available")
+	}
+	if st.Mode != 0640 || st.Size != 5 || st.Nlink != 1 || st.Ino != want.Ino ||
+		st.DevMajor != want.DevMajor || st.DevMinor != want.DevMinor ||
+		!st.Mtime.Equal(want.Mtime) || !st.Mtime.Equal(fi.ModTime()) {
+		t.Errorf("Statx = %+v; want %+v", st, want)
+	}
+	if uint64(syscall.Getuid()) != uint64(st.UID) {
+		t.Errorf("Statx: UID = %d; want %d", st.UID, syscall.Getuid())
+	}
+	if st.Mask&StatxBtime != 0 && (st.Btime.IsZero() || st.Btime.After(st.Mtime)) {
+		t.Errorf("Statx: invalid birth time %s, modification time %s", st.Btime, st.Mtime)
+	}
+	if st.Mask&StatxMntID != 0 {
+		m, err := MountOf(name)
+		if err == nil && uint64(m.ID) != st.MntID {
+			t.Errorf("Statx: mount ID %d; want %d", st.MntID, m.ID)
+		}
+	}
+	if want.Mask != StatxBasicStats || !want.Btime.IsZero() {
+		t.Errorf("FileStatx: mask %#x, birth time %s", want.Mask, want.Btime)
+	}
+
+	lst, err := Lstatx(link, StatxBasicStats)
+	if err != nil {
+		t.Fatalf("Lstatx: %v", err)
+	}
+	if lst.Mode&os.ModeSymlink == 0 || lst.Size != int64(len(name)) {
+		t.Errorf("Lstatx of link: mode %s size %d", lst.Mode, lst.Size)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fst, err := Fstatx(f, StatxBasicStats)
+	if err != nil {
+		t.Fatalf("Fstatx: %v", err)
+	}
+	if fst.Ino != st.Ino || fst.Size != st.Size {
+		t.Errorf("Fstatx = %+v; want %+v", fst, st)
+	}
+
+	_, err = Statx(filepath.Join(dir, "missing"), StatxBasicStats)
+	if perr, ok := err.(*os.PathError); !ok || perr.Op != "statx" || !os.IsNotExist(err) {
+		t.Errorf("Statx of missing file: got %#v", err)
+	}
+}
+
+func TestStatxDevice(t *testing.T) {
+	st, err := Statx("/dev/null", StatxBasicStats)
+	if err != nil {
+		t.Skip(err)
+	}
+	// /dev/null is character device 1:3.
+	if st.Mode&os.ModeCharDevice == 0 || st.RdevMajor != 1 || st.RdevMinor != 3 {
+		t.Errorf("Statx(/dev/null): mode %s device %d:%d", st.Mode, st.RdevMajor, st.RdevMinor)
+	}
+	fi, err := Stat("/dev/null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st2, _ := FileStatx(fi); st2.RdevMajor != 1 || st2.RdevMinor != 3 || st2.Mode != fi.Mode() {
+		t.Errorf("FileStatx(/dev/null): mode %s device %d:%d", st2.Mode, st2.RdevMajor, st2.RdevMinor)
+	}
+}
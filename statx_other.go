@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+import "os"
+
+func statx(name string, mask StatxMask, follow bool) (*StatxInfo, error) {
+	op := "statx"
+	if !follow {
+		op = "lstatx"
+	}
+	return nil, &os.PathError{Op: op, Path: name, Err: ErrNotSupported}
+}
+
+func fstatx(f *os.File, mask StatxMask) (*StatxInfo, error) {
+	return nil, &os.PathError{Op: "fstatx", Path: f.Name(), Err: ErrNotSupported}
+}
+
+func fileStatx(fi os.FileInfo) (*StatxInfo, bool) {
+	return nil, false
+}
@@ -0,0 +1,8 @@
+package fs
+
+// System call numbers not defined by package syscall.
+const (
+	_SYS_FCHMODAT2 = 452
+	_SYS_RENAMEAT2 = 382
+	_SYS_STATX     = 397
+)
@@ -0,0 +1,11 @@
+//go:build arm64 || riscv64 || loong64
+// +build arm64 riscv64 loong64
+
+package fs
+
+// System call numbers not defined by package syscall.
+const (
+	_SYS_FCHMODAT2 = 452
+	_SYS_RENAMEAT2 = 276
+	_SYS_STATX     = 291
+)
@@ -0,0 +1,11 @@
+//go:build mips64 || mips64le
+// +build mips64 mips64le
+
+package fs
+
+// System call numbers not defined by package syscall.
+const (
+	_SYS_FCHMODAT2 = 5452
+	_SYS_RENAMEAT2 = 5311
+	_SYS_STATX     = 5326
+)
@@ -0,0 +1,11 @@
+//go:build mips || mipsle
+// +build mips mipsle
+
+package fs
+
+// System call numbers not defined by package syscall.
+const (
+	_SYS_FCHMODAT2 = 4452
+	_SYS_RENAMEAT2 = 4351
+	_SYS_STATX     = 4366
+)
@@ -0,0 +1,11 @@
+//go:build ppc64 || ppc64le
+// +build ppc64 ppc64le
+
+package fs
+
+// System call numbers not defined by package syscall.
+const (
+	_SYS_FCHMODAT2 = 452
+	_SYS_RENAMEAT2 = 357
+	_SYS_STATX     = 383
+)
@@ -0,0 +1,214 @@
+// The below code uses portions of the Go standard library.
+// The full license can be found in fs.go.
+//
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errPatternHasSeparator = errors.New("pattern contains path separator")
+
+// Random number state, seeded from the time and process ID so that
+// concurrent processes are unlikely to try the same names.
+var (
+	randmu  sync.Mutex
+	randnum uint32
+)
+
+func reseed() uint32 {
+	return uint32(time.Now().UnixNano() + int64(os.Getpid()))
+}
+
+func nextRandom() string {
+	randmu.Lock()
+	r := randnum
+	if r == 0 {
+		r = reseed()
+	}
+	r = r*1664525 + 1013904223 // constants from Numerical Recipes
+	randnum = r
+	randmu.Unlock()
+	return strconv.FormatUint(uint64(r), 10)
+}
+
+// CreateTemp creates a new temporary file in the directory dir, opens the
+// file for reading and writing, and returns the resulting file. The
+// filename is generated by taking pattern and adding a random string to
+// the end. If pattern includes a "*", the random string replaces the last
+// "*". If dir is the empty string, CreateTemp uses the default directory
+// for temporary files, as returned by os.TempDir. Multiple programs or
+// goroutines calling CreateTemp simultaneously will not choose the same
+// file. The caller can use the file's Name method to find the pathname of
+// the file. It is the caller's responsibility to remove the file when it
+// is no longer needed.
+//
+// Unlike os.CreateTemp, the file is created with OpenFile and may be in a
+// directory whose path is longer than MAX_PATH on Windows.
+func CreateTemp(dir, pattern string) (*os.File, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	prefix, suffix, err := prefixAndSuffix(pattern)
+	if err != nil {
+		return nil, &os.PathError{Op: "createtemp", Path: pattern, Err: err}
+	}
+	prefix = joinPath(dir, prefix)
+
+	try := 0
+	for {
+		name := prefix + nextRandom() + suffix
+		f, err := OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			if try++; try < 10000 {
+				if try%100 == 0 {
+					randmu.Lock()
+					randnum = reseed()
+					randmu.Unlock()
+				}
+				continue
+			}
+			return nil, &os.PathError{Op: "createtemp", Path: prefix + "*" + suffix, Err: os.ErrExist}
+		}
+		return f, err
+	}
+}
+
+// MkdirTemp creates a new temporary directory in the directory dir and
+// returns the pathname of the new directory. The new directory's name is
+// generated by adding a random string to the end of pattern. If pattern
+// includes a "*", the random string replaces the last "*" instead. If dir
+// is the empty string, MkdirTemp uses the default directory for temporary
+// files, as returned by os.TempDir. Multiple programs or goroutines calling
+// MkdirTemp simultaneously will not choose the same directory. It is the
+// caller's responsibility to remove the directory when it is no longer
+// needed.
+//
+// Unlike os.MkdirTemp, the directory is created with Mkdir and may be in a
+// directory whose path is longer than MAX_PATH on Windows.
+func MkdirTemp(dir, pattern string) (string, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	prefix, suffix, err := prefixAndSuffix(pattern)
+	if err != nil {
+		return "", &os.PathError{Op: "mkdirtemp", Path: pattern, Err: err}
+	}
+	prefix = joinPath(dir, prefix)
+
+	try := 0
+	for {
+		name := prefix + nextRandom() + suffix
+		err := Mkdir(name, 0700)
+		if err == nil {
+			return name, nil
+		}
+		if os.IsExist(err) {
+			if try++; try < 10000 {
+				if try%100 == 0 {
+					randmu.Lock()
+					randnum = reseed()
+					randmu.Unlock()
+				}
+				continue
+			}
+			return "", &os.PathError{Op: "mkdirtemp", Path: prefix + "*" + suffix, Err: os.ErrExist}
+		}
+		if os.IsNotExist(err) {
+			if _, err := Stat(dir); os.IsNotExist(err) {
+				return "", err
+			}
+		}
+		return "", err
+	}
+}
+
+// prefixAndSuffix splits pattern by the last wildcard "*", if applicable,
+// returning prefix as the part before "*" and suffix as the part after
+// "*".
+func prefixAndSuffix(pattern string) (prefix, suffix string, err error) {
+	for i := 0; i < len(pattern); i++ {
+		if os.IsPathSeparator(pattern[i]) {
+			return "", "", errPatternHasSeparator
+		}
+	}
+	if pos := strings.LastIndexByte(pattern, '*'); pos != -1 {
+		prefix, suffix = pattern[:pos], pattern[pos+1:]
+	} else {
+		prefix = pattern
+	}
+	return prefix, suffix, nil
+}
+
+func joinPath(dir, name string) string {
+	if len(dir) > 0 && os.IsPathSeparator(dir[len(dir)-1]) {
+		return dir + name
+	}
+	return dir + string(os.PathSeparator) + name
+}
+
+// TB is the subset of testing.TB used by TempTree.
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// TempTree creates a temporary directory containing files and returns its
+// path. The directory is removed with RemoveAll when the test finishes.
+//
+// The keys of files are slash-separated paths relative to the directory
+// and the values the contents of the files. Keys ending in a slash create
+// directories, parent directories are created as needed.
+func TempTree(tb TB, files map[string]string) string {
+	tb.Helper()
+	root, err := MkdirTemp("", "fs-tree-")
+	if err != nil {
+		tb.Fatalf("TempTree: %v", err)
+	}
+	tb.Cleanup(func() {
+		RemoveAll(root)
+	})
+	if err := writeTree(root, files); err != nil {
+		tb.Fatalf("TempTree: %v", err)
+	}
+	return root
+}
+
+// writeTree creates files below root, see TempTree.
+func writeTree(root string, files map[string]string) error {
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		f, err := OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCreateTempPattern(t *testing.T) {
+	dir := TempTree(t, nil)
+	tests := []struct{ pattern, prefix, suffix string }{
+		{"tempfile_test", "tempfile_test", ""},
+		{"tempfile_test*", "tempfile_test", ""},
+		{"tempfile_test*xyz", "tempfile_test", "xyz"},
+		{"a*b*c", "a*b", "c"},
+	}
+	for _, test := range tests {
+		f, err := CreateTemp(dir, test.pattern)
+		if err != nil {
+			t.Fatalf("CreateTemp(%q): %v", test.pattern, err)
+		}
+		f.Close()
+		base := filepath.Base(f.Name())
+		if filepath.Dir(f.Name()) != dir {
+			t.Errorf("CreateTemp(%q) = %q, not in %q", test.pattern, f.Name(), dir)
+		}
+		if !strings.HasPrefix(base, test.prefix) || !strings.HasSuffix(base, test.suffix) ||
+			len(base) <= len(test.prefix)+len(test.suffix) {
+			t.Errorf("CreateTemp(%q) = %q, want %q + random + %q", test.pattern, base, test.prefix, test.suffix)
+		}
+		if runtime.GOOS != "windows" {
+			checkMode(t, f.Name(), 0600)
+		}
+	}
+}
+
+func TestTempBadPattern(t *testing.T) {
+	dir := TempTree(t, nil)
+	const sep = string(os.PathSeparator)
+	for _, pattern := range []string{"ab" + sep + "cd*", "ab*" + sep + "cd", sep + "x"} {
+		if f, err := CreateTemp(dir, pattern); err == nil {
+			f.Close()
+			t.Errorf("CreateTemp(%q): expected error", pattern)
+		} else if !errors.Is(err, errPatternHasSeparator) {
+			t.Errorf("CreateTemp(%q): error = %v, want %v", pattern, err, errPatternHasSeparator)
+		}
+		if _, err := MkdirTemp(dir, pattern); !errors.Is(err, errPatternHasSeparator) {
+			t.Errorf("MkdirTemp(%q): error = %v, want %v", pattern, err, errPatternHasSeparator)
+		}
+	}
+}
+
+func TestMkdirTemp(t *testing.T) {
+	dir := TempTree(t, nil)
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		name, err := MkdirTemp(dir, "mkdirtemp-*.d")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[name] {
+			t.Fatalf("MkdirTemp returned %q twice", name)
+		}
+		seen[name] = true
+		if !strings.HasSuffix(name, ".d") || filepath.Dir(name) != dir {
+			t.Errorf("MkdirTemp = %q", name)
+		}
+		fi, err := Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fi.IsDir() {
+			t.Errorf("%s: not a directory", name)
+		}
+		if runtime.GOOS != "windows" {
+			checkMode(t, name, 0700)
+		}
+	}
+}
+
+func TestMkdirTempMissingDir(t *testing.T) {
+	dir := filepath.Join(TempTree(t, nil), "missing")
+	_, err := MkdirTemp(dir, "x")
+	if !os.IsNotExist(err) {
+		t.Fatalf("MkdirTemp in missing dir: error = %v, want not exist", err)
+	}
+	if _, err := CreateTemp(dir, "x"); !os.IsNotExist(err) {
+		t.Fatalf("CreateTemp in missing dir: error = %v, want not exist", err)
+	}
+}
+
+func TestTempTree(t *testing.T) {
+	var root string
+	t.Run("tree", func(t *testing.T) {
+		root = TempTree(t, map[string]string{
+			"a.txt":     "a",
+			"b/c.txt":   "c",
+			"b/d/":      "",
+			"e/f/g.txt": "g",
+		})
+		for name, want := range map[string]string{"a.txt": "a", "b/c.txt": "c", "e/f/g.txt": "g"} {
+			if got := readTestFile(t, filepath.Join(root, filepath.FromSlash(name))); got != want {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+		if fi, err := Stat(filepath.Join(root, "b", "d")); err != nil || !fi.IsDir() {
+			t.Errorf("b/d: not a directory: %v", err)
+		}
+	})
+	if _, err := Lstat(root); !os.IsNotExist(err) {
+		t.Errorf("TempTree root %s was not removed: %v", root, err)
+	}
+}
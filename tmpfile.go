@@ -0,0 +1,42 @@
+package fs
+
+import (
+	"errors"
+	"os"
+)
+
+var errNotPublishable = errors.New("file was published or not created by CreateUnnamed")
+
+// createNamedTemp creates the hidden temporary file used by CreateUnnamed
+// if unnamed files are not supported.
+func createNamedTemp(dir string) (*os.File, error) {
+	return CreateTemp(dir, ".unnamed-")
+}
+
+// publishNamed publishes a file created by createNamedTemp by renaming
+// it.
+func publishNamed(f *os.File, name string) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return &os.PathError{Op: "publish", Path: name, Err: err}
+	}
+	// The file is renamed by name, make sure that it still is the file.
+	if cur, err := Lstat(f.Name()); err != nil || !SameFile(fi, cur) {
+		return &os.PathError{Op: "publish", Path: name, Err: errNotPublishable}
+	}
+	if err := Rename(f.Name(), name); err != nil {
+		return &os.PathError{Op: "publish", Path: name, Err: underlyingError(err)}
+	}
+	return nil
+}
+
+// underlyingError returns the error wrapped by a *PathError or *LinkError.
+func underlyingError(err error) error {
+	switch e := err.(type) {
+	case *os.PathError:
+		return e.Err
+	case *os.LinkError:
+		return e.Err
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package fs
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+const _O_TMPFILE = 020000000 | syscall.O_DIRECTORY
+
+func createUnnamed(dir string) (*os.File, error) {
+	var fd int
+	err := ignoringEINTR(func() error {
+		var err error
+		fd, err = syscall.Open(dir, _O_TMPFILE|syscall.O_RDWR|syscall.O_CLOEXEC, 0600)
+		return err
+	})
+	switch err {
+	case nil:
+		// The file has no name, its name in /proc refers to it and can
+		// not be mistaken for the directory.
+		return os.NewFile(uintptr(fd), "/proc/self/fd/"+strconv.Itoa(fd)), nil
+	case syscall.EISDIR, syscall.EOPNOTSUPP, syscall.EINVAL:
+		// Either the kernel or the file system does not support O_TMPFILE.
+		return createNamedTemp(dir)
+	}
+	return nil, &os.PathError{Op: "createunnamed", Path: dir, Err: err}
+}
+
+func linkat(olddirfd int, oldpath string, newpath string, flags int) error {
+	p1, err := syscall.BytePtrFromString(oldpath)
+	if err != nil {
+		return err
+	}
+	p2, err := syscall.BytePtrFromString(newpath)
+	if err != nil {
+		return err
+	}
+	newdirfd := _AT_FDCWD
+	_, _, e := syscall.Syscall6(syscall.SYS_LINKAT, uintptr(olddirfd), uintptr(unsafe.Pointer(p1)),
+		uintptr(newdirfd), uintptr(unsafe.Pointer(p2)), uintptr(flags), 0)
+	return errnoErr(e)
+}
+
+// linkFd links the file referred to by fd as name. Linking with
+// AT_EMPTY_PATH requires the CAP_DAC_READ_SEARCH capability, so the link in
+// /proc is tried first.
+func linkFd(fd uintptr, name string) error {
+	err := linkat(_AT_FDCWD, "/proc/self/fd/"+strconv.Itoa(int(fd)), name, _AT_SYMLINK_FOLLOW)
+	if err == syscall.ENOENT {
+		if _, serr := os.Stat("/proc/self/fd"); serr != nil {
+			err = linkat(int(fd), "", name, _AT_EMPTY_PATH)
+		}
+	}
+	return err
+}
+
+func publish(f *os.File, name string) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return &os.PathError{Op: "publish", Path: name, Err: err}
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || st.Nlink != 0 {
+		// A named temporary file.
+		return publishNamed(f, name)
+	}
+	err = controlFd(f, func(fd uintptr) error {
+		err := linkFd(fd, name)
+		if err != syscall.EEXIST {
+			return err
+		}
+		// Link the file with a temporary name and rename it over the
+		// existing file.
+		for i := 0; i < 10000; i++ {
+			tmp := name + "." + strconv.FormatUint(uint64(rand.Uint32()), 10) + ".tmp"
+			err := linkFd(fd, tmp)
+			if err == syscall.EEXIST {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := syscall.Rename(tmp, name); err != nil {
+				syscall.Unlink(tmp)
+				return err
+			}
+			return nil
+		}
+		return syscall.EEXIST
+	})
+	if err != nil {
+		return &os.PathError{Op: "publish", Path: name, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+import "os"
+
+func createUnnamed(dir string) (*os.File, error) {
+	return createNamedTemp(dir)
+}
+
+func publish(f *os.File, name string) error {
+	return publishNamed(f, name)
+}
@@ -0,0 +1,151 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isUnnamed reports whether f has no name in the file system.
+func isUnnamed(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	cur, err := Lstat(f.Name())
+	return err != nil || !SameFile(fi, cur)
+}
+
+func testPublish(t *testing.T, dir string, create func(dir string) (*os.File, error)) {
+	f, err := create(dir)
+	if err != nil {
+		t.Fatalf("CreateUnnamed: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("contents"); err != nil {
+		t.Fatal(err)
+	}
+	unnamed := isUnnamed(f)
+	if unnamed {
+		if names := dirNames(t, dir); len(names) != 0 {
+			t.Errorf("unnamed file is visible: %q", names)
+		}
+	}
+
+	name := filepath.Join(dir, "file")
+	if err := Publish(f, name); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if names := dirNames(t, dir); !reflect.DeepEqual(names, []string{"file"}) {
+		t.Errorf("after Publish: directory contains %q; want [file]", names)
+	}
+	if s := readTestFile(t, name); s != "contents" {
+		t.Errorf("published file contains %q", s)
+	}
+	if err := Publish(f, filepath.Join(dir, "again")); err == nil {
+		t.Error("Publish of published file: expected error")
+	}
+
+	// An existing file is replaced.
+	f2, err := create(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f2.Close()
+	f2.WriteString("replaced")
+	if err := Publish(f2, name); err != nil {
+		t.Fatalf("Publish over existing file: %v", err)
+	}
+	if s := readTestFile(t, name); s != "replaced" {
+		t.Errorf("replaced file contains %q", s)
+	}
+	if names := dirNames(t, dir); !reflect.DeepEqual(names, []string{"file"}) {
+		t.Errorf("after replacing: directory contains %q; want [file]", names)
+	}
+
+	// A file is discarded by closing and removing it, closing an unnamed
+	// file already removes it.
+	f3, err := create(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f3.Close()
+	if unnamed {
+		if names := dirNames(t, dir); !reflect.DeepEqual(names, []string{"file"}) {
+			t.Errorf("after closing unnamed file: directory contains %q", names)
+		}
+	}
+	if err := Remove(f3.Name()); err != nil && !os.IsNotExist(err) {
+		t.Errorf("Remove of discarded file: %v", err)
+	}
+	if names := dirNames(t, dir); !reflect.DeepEqual(names, []string{"file"}) {
+		t.Errorf("after discarding file: directory contains %q", names)
+	}
+}
+
+func TestCreateUnnamed(t *testing.T) {
+	dir := newDir("TestCreateUnnamed", t)
+	defer RemoveAll(dir)
+	testPublish(t, dir, CreateUnnamed)
+}
+
+func TestCreateUnnamedTmpfs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("test requires tmpfs")
+	}
+	fs, err := StatFS("/dev/shm")
+	if err != nil || fs.Type != "tmpfs" {
+		t.Skip("/dev/shm is not a tmpfs")
+	}
+	dir, err := MkdirTemp("/dev/shm", "TestCreateUnnamedTmpfs")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer RemoveAll(dir)
+
+	f, err := CreateUnnamed(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isUnnamed(f) {
+		t.Error("CreateUnnamed on tmpfs returned a named file")
+	}
+	f.Close()
+	testPublish(t, dir, CreateUnnamed)
+}
+
+func TestCreateUnnamedFallback(t *testing.T) {
+	dir := newDir("TestCreateUnnamedFallback", t)
+	defer RemoveAll(dir)
+	testPublish(t, dir, createNamedTemp)
+}
+
+func TestCreateUnnamedMissingDir(t *testing.T) {
+	dir := newDir("TestCreateUnnamedMissingDir", t)
+	defer RemoveAll(dir)
+	_, err := CreateUnnamed(filepath.Join(dir, "missing"))
+	if !os.IsNotExist(err) {
+		t.Errorf("CreateUnnamed in missing directory: got %v; want not exist error", err)
+	}
+	if _, ok := err.(*os.PathError); !ok {
+		t.Errorf("CreateUnnamed: error %#v is not a *PathError", err)
+	}
+}
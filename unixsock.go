@@ -0,0 +1,134 @@
+package fs
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+)
+
+var errSocketNameTooLong = errors.New("socket name too long")
+
+// A UnixListener is a Unix domain socket listener created by ListenUnix.
+type UnixListener struct {
+	*net.UnixListener
+	addr   *net.UnixAddr
+	unlink bool // remove the socket file on Close
+}
+
+// Addr returns the listener's network address, the name passed to
+// ListenUnix.
+func (l *UnixListener) Addr() net.Addr { return l.addr }
+
+// Close stops listening and removes the socket file. Already accepted
+// connections are not closed.
+func (l *UnixListener) Close() error {
+	err := l.UnixListener.Close()
+	if l.unlink {
+		Remove(l.addr.Name)
+	}
+	return err
+}
+
+// ListenUnix announces on the Unix domain socket name, network must be
+// "unix" or "unixpacket". Unlike net.ListenUnix, name may be longer than
+// sun_path (108 bytes on Linux, 104 on most other platforms). Only the last
+// element of name still has to fit.
+//
+// Long names are bound relative to the directory containing them, which on
+// Linux is opened and accessed via /proc/self/fd. Otherwise, or if /proc
+// is not available, a symbolic link to the directory is created in the
+// temporary directory and removed once the socket is bound. The addresses
+// of accepted connections are those short names.
+func ListenUnix(network, name string) (*UnixListener, error) {
+	addr := &net.UnixAddr{Name: name, Net: network}
+	var l *net.UnixListener
+	err := withSocketPath(name, func(path string) error {
+		var err error
+		l, err = net.ListenUnix(network, &net.UnixAddr{Name: path, Net: network})
+		return err
+	})
+	if err != nil {
+		return nil, socketError("listen", addr, err)
+	}
+	ul := &UnixListener{UnixListener: l, addr: addr}
+	if name != l.Addr().String() {
+		// The listener would remove its short name, which is not valid
+		// anymore.
+		noUnlinkOnClose(l)
+		ul.unlink = true
+	}
+	return ul, nil
+}
+
+// noUnlinkOnClose stops l from removing its socket file on Close. The
+// method is missing on Plan 9, which has no Unix domain sockets.
+func noUnlinkOnClose(l *net.UnixListener) {
+	if u, ok := interface{}(l).(interface{ SetUnlinkOnClose(bool) }); ok {
+		u.SetUnlinkOnClose(false)
+	}
+}
+
+// DialUnix connects to the Unix domain socket name, network must be
+// "unix", "unixgram" or "unixpacket". Like ListenUnix, name may be longer
+// than sun_path.
+func DialUnix(network, name string) (*net.UnixConn, error) {
+	addr := &net.UnixAddr{Name: name, Net: network}
+	var c *net.UnixConn
+	err := withSocketPath(name, func(path string) error {
+		var err error
+		c, err = net.DialUnix(network, nil, &net.UnixAddr{Name: path, Net: network})
+		return err
+	})
+	if err != nil {
+		return nil, socketError("dial", addr, err)
+	}
+	return c, nil
+}
+
+// withSocketPath calls fn with a name of the socket name that fits into
+// sun_path.
+func withSocketPath(name string, fn func(path string) error) error {
+	if len(name) < sunPathMax || hasAbstractSockets && name[0] == '@' {
+		// Short or abstract.
+		return fn(name)
+	}
+	dir, base := filepath.Split(name)
+	if len(base) >= sunPathMax {
+		return errSocketNameTooLong
+	}
+	alias, release, err := aliasDir(filepath.Clean(dir))
+	if err != nil {
+		return err
+	}
+	defer release()
+	path := alias + string(filepath.Separator) + base
+	if len(path) >= sunPathMax {
+		return errSocketNameTooLong
+	}
+	return fn(path)
+}
+
+// aliasDirSymlink returns a short name of dir by creating a symbolic link
+// to it in the temporary directory, which release removes.
+func aliasDirSymlink(dir string) (alias string, release func(), err error) {
+	tmp, err := MkdirTemp("", "sock")
+	if err != nil {
+		return "", nil, err
+	}
+	alias = filepath.Join(tmp, "d")
+	if err := Symlink(dir, alias); err != nil {
+		RemoveAll(tmp)
+		return "", nil, err
+	}
+	return alias, func() { RemoveAll(tmp) }, nil
+}
+
+// socketError returns err as a *net.OpError for addr.
+func socketError(op string, addr *net.UnixAddr, err error) error {
+	if oe, ok := err.(*net.OpError); ok {
+		e := *oe
+		e.Addr = addr
+		return &e
+	}
+	return &net.OpError{Op: op, Net: addr.Net, Addr: addr, Err: err}
+}
@@ -0,0 +1,23 @@
+package fs
+
+import "os"
+
+// sunPathMax is the size of sun_path of struct sockaddr_un.
+const sunPathMax = 108
+
+// hasAbstractSockets reports whether names starting with '@' refer to
+// sockets in the abstract namespace rather than to files.
+const hasAbstractSockets = true
+
+// aliasDir returns a short name of dir, which is valid until release is
+// called.
+func aliasDir(dir string) (alias string, release func(), err error) {
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		return aliasDirSymlink(dir)
+	}
+	f, err := Open(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	return procFdPath(f), func() { f.Close() }, nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+// sunPathMax is the size of sun_path of struct sockaddr_un, which is 104
+// bytes on the BSDs and 108 on Windows.
+const sunPathMax = 104
+
+// hasAbstractSockets reports whether names starting with '@' refer to
+// sockets in the abstract namespace rather than to files, which is only
+// the case on Linux.
+const hasAbstractSockets = false
+
+// aliasDir returns a short name of dir, which is valid until release is
+// called.
+func aliasDir(dir string) (alias string, release func(), err error) {
+	return aliasDirSymlink(dir)
+}
@@ -0,0 +1,130 @@
+package fs
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func testUnixSocket(t *testing.T, name string) {
+	l, err := ListenUnix("unix", name)
+	if err != nil {
+		t.Fatalf("ListenUnix: %v", err)
+	}
+	defer l.Close()
+	if got := l.Addr().String(); got != name {
+		t.Errorf("Addr = %q, want %q", got, name)
+	}
+	if fi, err := Lstat(name); err != nil || fi.Mode()&os.ModeSocket == 0 {
+		t.Fatalf("socket %s was not created: %v", name, err)
+	}
+
+	const msg = "hello, socket"
+	errc := make(chan error, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		_, err = io.WriteString(c, msg)
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+		errc <- err
+	}()
+	c, err := DialUnix("unix", name)
+	if err != nil {
+		t.Fatalf("DialUnix: %v", err)
+	}
+	defer c.Close()
+	got, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != msg {
+		t.Errorf("read %q, want %q", got, msg)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lstat(name); !os.IsNotExist(err) {
+		t.Errorf("socket %s was not removed on Close: %v", name, err)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	switch runtime.GOOS {
+	case "js", "plan9", "wasip1", "windows":
+		t.Skipf("skipping on %s", runtime.GOOS)
+	}
+	dir := TempTree(t, nil)
+	t.Run("Short", func(t *testing.T) {
+		testUnixSocket(t, filepath.Join(dir, "short.sock"))
+	})
+	t.Run("Long", func(t *testing.T) {
+		long := dir
+		for len(long) < 220 {
+			long = filepath.Join(long, strings.Repeat("d", 50))
+		}
+		if err := MkdirAll(long, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testUnixSocket(t, filepath.Join(long, "long.sock"))
+	})
+	t.Run("LongBase", func(t *testing.T) {
+		// Only the alias of the directory and the last element have to
+		// fit, the alias in /proc is short.
+		if runtime.GOOS != "linux" {
+			t.Skipf("skipping on %s", runtime.GOOS)
+		}
+		if _, err := os.Stat("/proc/self/fd"); err != nil {
+			t.Skip(err)
+		}
+		long := filepath.Join(dir, strings.Repeat("b", 200))
+		if err := Mkdir(long, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testUnixSocket(t, filepath.Join(long, strings.Repeat("s", 70)))
+	})
+	t.Run("Symlink", func(t *testing.T) {
+		// The fallback used if /proc is not available.
+		long := filepath.Join(dir, strings.Repeat("s", 200))
+		if err := Mkdir(long, 0755); err != nil {
+			t.Fatal(err)
+		}
+		alias, release, err := aliasDirSymlink(long)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := net.ListenUnix("unix", &net.UnixAddr{Name: filepath.Join(alias, "sock"), Net: "unix"})
+		release()
+		if err != nil {
+			t.Fatal(err)
+		}
+		noUnlinkOnClose(l)
+		defer l.Close()
+		c, err := DialUnix("unix", filepath.Join(long, "sock"))
+		if err != nil {
+			t.Fatalf("DialUnix: %v", err)
+		}
+		c.Close()
+	})
+}
+
+func TestUnixSocketNameTooLong(t *testing.T) {
+	name := filepath.Join(TempTree(t, nil), strings.Repeat("x", 200))
+	if _, err := ListenUnix("unix", name); err == nil {
+		t.Fatal("ListenUnix: expected error")
+	} else if !strings.Contains(err.Error(), name) {
+		t.Errorf("ListenUnix: error %q does not contain the name", err)
+	}
+}
@@ -0,0 +1,92 @@
+package fs
+
+import (
+	"os"
+	"time"
+)
+
+// A FileTime is a timestamp to set with Utimes and its variants. Besides a
+// specific time, it may leave the timestamp unchanged or set it to the
+// current time.
+type FileTime struct {
+	t    time.Time
+	kind fileTimeKind
+}
+
+type fileTimeKind uint8
+
+const (
+	timeOmit fileTimeKind = iota
+	timeNow
+	timeAt
+)
+
+var (
+	// TimeOmit leaves a timestamp unchanged, it is the zero FileTime.
+	TimeOmit = FileTime{}
+
+	// TimeNow sets a timestamp to the current time. On Linux, unlike
+	// TimeAt with the result of time.Now, it only requires write access to
+	// the file, not ownership.
+	TimeNow = FileTime{kind: timeNow}
+)
+
+// TimeAt returns a FileTime that sets a timestamp to t.
+func TimeAt(t time.Time) FileTime {
+	return FileTime{t: t, kind: timeAt}
+}
+
+// Omit reports whether the timestamp is left unchanged.
+func (t FileTime) Omit() bool { return t.kind == timeOmit }
+
+// resolve returns the time to set, cur is used if the timestamp is
+// omitted.
+func (t FileTime) resolve(cur time.Time) time.Time {
+	switch t.kind {
+	case timeNow:
+		return time.Now()
+	case timeAt:
+		return t.t
+	}
+	return cur
+}
+
+// Utimes changes the access and modification times of the named file with
+// nanosecond precision. If the file is a symbolic link, it changes the
+// times of the link's target.
+//
+// The underlying filesystem may truncate or round the values to a less
+// precise time unit.
+// If there is an error, it will be of type *PathError.
+func Utimes(name string, atime, mtime FileTime) error {
+	return utimes(name, atime, mtime, true)
+}
+
+// Lutimes is like Utimes, but if the file is a symbolic link it changes
+// the times of the link itself. Not all platforms support changing the
+// times of symbolic links, on these the error wraps ErrNotSupported.
+func Lutimes(name string, atime, mtime FileTime) error {
+	return utimes(name, atime, mtime, false)
+}
+
+// Futimes changes the access and modification times of f.
+// If there is an error, it will be of type *PathError.
+func Futimes(f *os.File, atime, mtime FileTime) error {
+	return futimes(f, atime, mtime)
+}
+
+// Lchtimes is like Chtimes, but if the file is a symbolic link it changes
+// the times of the link itself. As with Chtimes, a zero time.Time value
+// leaves the corresponding timestamp unchanged.
+func Lchtimes(name string, atime time.Time, mtime time.Time) error {
+	return utimes(name, chtime(atime), chtime(mtime), false)
+}
+
+// chtime returns the FileTime setting a timestamp to t, or TimeOmit if t
+// is zero.
+func chtime(t time.Time) FileTime {
+	if t.IsZero() {
+		return TimeOmit
+	}
+	return TimeAt(t)
+}
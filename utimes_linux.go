@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	_UTIME_NOW  = (1 << 30) - 1
+	_UTIME_OMIT = (1 << 30) - 2
+)
+
+func timespec(t FileTime) syscall.Timespec {
+	switch t.kind {
+	case timeNow:
+		return syscall.Timespec{Nsec: _UTIME_NOW}
+	case timeAt:
+		// UnixNano overflows for times outside the years 1678 to 2262.
+		var ts syscall.Timespec
+		setInt(&ts.Sec, t.t.Unix())
+		setInt(&ts.Nsec, int64(t.t.Nanosecond()))
+		return ts
+	}
+	return syscall.Timespec{Nsec: _UTIME_OMIT}
+}
+
+// setInt sets the field of a Timespec, whose type depends on the
+// architecture, to v.
+func setInt[T int32 | int64](p *T, v int64) {
+	*p = T(v)
+}
+
+func utimensat(dirfd int, path *byte, atime, mtime FileTime, flags int) error {
+	ts := [2]syscall.Timespec{timespec(atime), timespec(mtime)}
+	_, _, e := syscall.Syscall6(syscall.SYS_UTIMENSAT, uintptr(dirfd), uintptr(unsafe.Pointer(path)),
+		uintptr(unsafe.Pointer(&ts[0])), uintptr(flags), 0, 0)
+	return errnoErr(e)
+}
+
+func utimes(name string, atime, mtime FileTime, follow bool) error {
+	op := "utimes"
+	flags := 0
+	if !follow {
+		op = "lutimes"
+		flags = _AT_SYMLINK_NOFOLLOW
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return &os.PathError{Op: op, Path: name, Err: err}
+	}
+	if err := utimensat(_AT_FDCWD, p, atime, mtime, flags); err != nil {
+		return &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return nil
+}
+
+func futimes(f *os.File, atime, mtime FileTime) error {
+	// With a nil path utimensat changes the times of the file referred to
+	// by the descriptor.
+	err := controlFd(f, func(fd uintptr) error {
+		return utimensat(int(fd), nil, atime, mtime, 0)
+	})
+	if err != nil {
+		return &os.PathError{Op: "futimes", Path: f.Name(), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// statTimes returns the access and modification times of the named file
+// with nanosecond precision.
+func statTimes(t *testing.T, name string) (atime, mtime time.Time) {
+	t.Helper()
+	fi, err := Lstat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := fi.Sys().(*syscall.Stat_t)
+	return time.Unix(st.Atim.Unix()), time.Unix(st.Mtim.Unix())
+}
+
+func TestUtimesPrecise(t *testing.T) {
+	dir := newDir("TestUtimesPrecise", t)
+	defer RemoveAll(dir)
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+	f, err := os.Create(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	atime := time.Unix(1000000000, 123456789)
+	mtime := time.Unix(1100000000, 987654321)
+	if err := Utimes(link, TimeAt(atime), TimeAt(mtime)); err != nil {
+		t.Fatal(err)
+	}
+	if a, m := statTimes(t, target); !a.Equal(atime) || !m.Equal(mtime) {
+		t.Fatalf("Utimes: got %s, %s; want %s, %s", a, m, atime, mtime)
+	}
+
+	// Only the modification time is changed.
+	mtime2 := time.Unix(1200000000, 1)
+	if err := Utimes(target, TimeOmit, TimeAt(mtime2)); err != nil {
+		t.Fatal(err)
+	}
+	if a, m := statTimes(t, target); !a.Equal(atime) || !m.Equal(mtime2) {
+		t.Errorf("Utimes omitting atime: got %s, %s; want %s, %s", a, m, atime, mtime2)
+	}
+
+	// Only the access time is changed, to now.
+	before := time.Now().Add(-time.Second)
+	if err := Futimes(f, TimeNow, TimeOmit); err != nil {
+		t.Fatal(err)
+	}
+	if a, m := statTimes(t, target); a.Before(before) || !m.Equal(mtime2) {
+		t.Errorf("Futimes: got %s, %s; want now, %s", a, m, mtime2)
+	}
+
+	// The times of the link change, not those of its target.
+	latime := time.Unix(900000000, 5)
+	lmtime := time.Unix(950000000, 6)
+	if err := Lchtimes(link, latime, lmtime); err != nil {
+		t.Fatalf("Lchtimes: %v", err)
+	}
+	if a, m := statTimes(t, link); !a.Equal(latime) || !m.Equal(lmtime) {
+		t.Errorf("Lchtimes: link times %s, %s; want %s, %s", a, m, latime, lmtime)
+	}
+	if _, m := statTimes(t, target); !m.Equal(mtime2) {
+		t.Errorf("Lchtimes: target mtime %s; want %s", m, mtime2)
+	}
+	if err := Lutimes(link, TimeOmit, TimeAt(mtime)); err != nil {
+		t.Fatalf("Lutimes: %v", err)
+	}
+	if a, m := statTimes(t, link); !a.Equal(latime) || !m.Equal(mtime) {
+		t.Errorf("Lutimes: link times %s, %s; want %s, %s", a, m, latime, mtime)
+	}
+
+	err = Lutimes(filepath.Join(dir, "missing"), TimeNow, TimeNow)
+	if perr, ok := err.(*os.PathError); !ok || perr.Op != "lutimes" || !os.IsNotExist(err) {
+		t.Errorf("Lutimes of missing file: got %#v", err)
+	}
+}
+
+func TestUtimesOutOfNanoRange(t *testing.T) {
+	var ts syscall.Timespec
+	if unsafe.Sizeof(ts.Sec) < 8 {
+		t.Skip("time_t has 32 bits")
+	}
+	// Times UnixNano can not represent.
+	for _, tm := range []time.Time{
+		time.Date(1600, 1, 2, 3, 4, 5, 6, time.UTC),
+		time.Date(3000, 1, 2, 3, 4, 5, 6, time.UTC),
+	} {
+		ts := timespec(TimeAt(tm))
+		if got := time.Unix(ts.Unix()); !got.Equal(tm) {
+			t.Errorf("timespec(%s) = %s", tm, got)
+		}
+	}
+
+	// Year 2300 is supported by the common file systems.
+	name := filepath.Join(TempTree(t, map[string]string{"file": ""}), "file")
+	mtime := time.Date(2300, 1, 2, 3, 4, 5, 6, time.UTC)
+	if err := Utimes(name, TimeOmit, TimeAt(mtime)); err != nil {
+		t.Fatal(err)
+	}
+	if _, m := statTimes(t, name); !m.Equal(mtime) {
+		t.Errorf("mtime = %s, want %s", m, mtime)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package fs
+
+import (
+	"os"
+	"time"
+)
+
+// On this platform the times are set with os.Chtimes, which requires both
+// times, omitted times are replaced by the current ones.
+
+func resolveTimes(fi os.FileInfo, atime, mtime FileTime) (time.Time, time.Time, bool) {
+	cur, ok := fileAtime(fi)
+	if !ok && atime.Omit() {
+		return time.Time{}, time.Time{}, false
+	}
+	return atime.resolve(cur), mtime.resolve(fi.ModTime()), true
+}
+
+func utimes(name string, atime, mtime FileTime, follow bool) error {
+	op := "utimes"
+	stat := Stat
+	if !follow {
+		op = "lutimes"
+		stat = Lstat
+	}
+	if atime.Omit() && mtime.Omit() {
+		_, err := stat(name)
+		return err
+	}
+	fi, err := stat(name)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return &os.PathError{Op: op, Path: name, Err: ErrNotSupported}
+	}
+	a, m, ok := resolveTimes(fi, atime, mtime)
+	if !ok {
+		return &os.PathError{Op: op, Path: name, Err: ErrNotSupported}
+	}
+	return Chtimes(name, a, m)
+}
+
+// futimes sets the times of the file by name, since there is no portable
+// way to set them through the descriptor.
+func futimes(f *os.File, atime, mtime FileTime) error {
+	if atime.Omit() && mtime.Omit() {
+		return nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	a, m, ok := resolveTimes(fi, atime, mtime)
+	if !ok {
+		return &os.PathError{Op: "futimes", Path: f.Name(), Err: ErrNotSupported}
+	}
+	return Chtimes(f.Name(), a, m)
+}
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUtimes(t *testing.T) {
+	f := newFile("TestUtimes", t)
+	name := f.Name()
+	defer Remove(name)
+	defer f.Close()
+
+	atime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	mtime := time.Date(2002, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := Utimes(name, TimeAt(atime), TimeAt(mtime)); err != nil {
+		t.Fatalf("Utimes: %v", err)
+	}
+	fi, err := Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.ModTime().Equal(mtime) {
+		t.Errorf("ModTime = %s; want %s", fi.ModTime(), mtime)
+	}
+
+	// Omitted times are left unchanged.
+	err = Utimes(name, TimeAt(mtime), TimeOmit)
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Utimes: %v", err)
+	}
+	if fi, err := Stat(name); err != nil || !fi.ModTime().Equal(mtime) {
+		t.Errorf("ModTime after omitting it = %v, %v; want %s", fi.ModTime(), err, mtime)
+	}
+
+	before := time.Now().Add(-time.Minute)
+	if err := Futimes(f, TimeOmit, TimeNow); err != nil {
+		t.Fatalf("Futimes: %v", err)
+	}
+	if fi, err := Stat(name); err != nil || fi.ModTime().Before(before) {
+		t.Errorf("ModTime after setting it to now = %v, %v; want after %s", fi.ModTime(), err, before)
+	}
+
+	if err := Utimes(name+".missing", TimeNow, TimeNow); !os.IsNotExist(err) {
+		t.Errorf("Utimes of missing file: got %v; want not exist error", err)
+	}
+}
+
+func TestLchtimesZero(t *testing.T) {
+	f := newFile("TestLchtimesZero", t)
+	name := f.Name()
+	defer Remove(name)
+	f.Close()
+
+	mtime := time.Date(2002, 3, 4, 5, 6, 7, 0, time.UTC)
+	err := Lchtimes(name, mtime, mtime)
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Lchtimes: %v", err)
+	}
+	// A zero time leaves the timestamp unchanged, like Chtimes.
+	if err := Lchtimes(name, time.Now(), time.Time{}); err != nil {
+		t.Fatalf("Lchtimes: %v", err)
+	}
+	if fi, err := Stat(name); err != nil || !fi.ModTime().Equal(mtime) {
+		t.Errorf("ModTime after Lchtimes with zero time = %v, %v; want %s", fi.ModTime(), err, mtime)
+	}
+}
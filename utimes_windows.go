@@ -0,0 +1,57 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func filetime(t FileTime) *syscall.Filetime {
+	if t.Omit() {
+		return nil
+	}
+	ft := syscall.NsecToFiletime(t.resolve(time.Time{}).UnixNano())
+	return &ft
+}
+
+func setFileTime(h syscall.Handle, atime, mtime FileTime) error {
+	return syscall.SetFileTime(h, nil, filetime(atime), filetime(mtime))
+}
+
+func utimes(name string, atime, mtime FileTime, follow bool) error {
+	op := "utimes"
+	flags := uint32(syscall.FILE_FLAG_BACKUP_SEMANTICS)
+	if !follow {
+		op = "lutimes"
+		flags |= syscall.FILE_FLAG_OPEN_REPARSE_POINT
+	}
+	p, err := winPath(name)
+	if err != nil {
+		return newPathError(op, name, err)
+	}
+	path, err := syscall.UTF16PtrFromString(p)
+	if err != nil {
+		return newPathError(op, name, err)
+	}
+	h, err := syscall.CreateFile(path, syscall.FILE_WRITE_ATTRIBUTES,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, flags, 0)
+	if err != nil {
+		return newPathError(op, name, err)
+	}
+	defer syscall.CloseHandle(h)
+	if err := setFileTime(h, atime, mtime); err != nil {
+		return newPathError(op, name, err)
+	}
+	return nil
+}
+
+func futimes(f *os.File, atime, mtime FileTime) error {
+	err := controlFd(f, func(fd uintptr) error {
+		return setFileTime(syscall.Handle(fd), atime, mtime)
+	})
+	if err != nil {
+		return newPathError("futimes", f.Name(), err)
+	}
+	return nil
+}
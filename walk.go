@@ -0,0 +1,248 @@
+// The below code uses portions of the Go standard library.
+// The full license can be found in fs.go.
+//
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var errUnsupportedFileType = errors.New("unsupported file type")
+
+// A Filter selects the files visited by WalkFilter, copied by CopyTree and
+// removed by RemoveTree.
+type Filter interface {
+	// Include reports whether the file at path, described by info, should
+	// be included. Excluding a directory also excludes everything below it.
+	Include(path string, info os.FileInfo) bool
+}
+
+// FilterFunc is an adapter to allow the use of ordinary functions as a
+// Filter.
+type FilterFunc func(path string, info os.FileInfo) bool
+
+// Include returns f(path, info).
+func (f FilterFunc) Include(path string, info os.FileInfo) bool {
+	return f(path, info)
+}
+
+// Walk walks the file tree rooted at root, calling walkFn for each file or
+// directory in the tree, including root. It behaves like filepath.Walk,
+// but uses this package's Lstat and Open so that long paths are handled.
+func Walk(root string, walkFn filepath.WalkFunc) error {
+	return WalkFilter(root, nil, walkFn)
+}
+
+// WalkFilter is like Walk, but files for which filter returns false are
+// not passed to walkFn and excluded directories are not descended into.
+// The root is never filtered. A nil filter includes every file.
+func WalkFilter(root string, filter Filter, walkFn filepath.WalkFunc) error {
+	info, err := Lstat(root)
+	if err != nil {
+		err = walkFn(root, nil, err)
+	} else {
+		err = walk(root, info, filter, walkFn)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// walk recursively descends path, calling walkFn.
+func walk(path string, info os.FileInfo, filter Filter, walkFn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return walkFn(path, info, nil)
+	}
+
+	names, err := readDirNames(path)
+	err1 := walkFn(path, info, err)
+	// If err != nil, walk can't walk into this directory.
+	// err1 != nil means walkFn want walk to skip this directory or stop walking.
+	// Therefore, if one of err and err1 isn't nil, walk will return.
+	if err != nil || err1 != nil {
+		// The caller's behavior is controlled by the return value, which is decided
+		// by walkFn. walkFn may ignore err and return nil.
+		// If walkFn returns SkipDir, it will be handled by the caller.
+		// So walk should return whatever walkFn returns.
+		return err1
+	}
+
+	for _, name := range names {
+		filename := filepath.Join(path, name)
+		fileInfo, err := Lstat(filename)
+		if err != nil {
+			if err := walkFn(filename, fileInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if filter != nil && !filter.Include(filename, fileInfo) {
+			continue
+		}
+		err = walk(filename, fileInfo, filter, walkFn)
+		if err != nil {
+			if !fileInfo.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readDirNames reads the directory named by dirname and returns
+// a sorted list of directory entries.
+func readDirNames(dirname string) ([]string, error) {
+	f, err := Open(dirname)
+	if err != nil {
+		return nil, err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CopyTree copies the file tree rooted at src to dst, creating dst if
+// necessary. Regular files, directories and symbolic links are copied and
+// their permission bits preserved; existing files in dst are overwritten.
+// Files for which filter returns false are not copied, a nil filter copies
+// every file. Any other file type results in an error.
+func CopyTree(dst, src string, filter Filter) error {
+	type dirMode struct {
+		path string
+		mode os.FileMode
+	}
+	// Directories are created writable so that they can be populated,
+	// their actual permissions are applied once the copy is complete.
+	var dirs []dirMode
+
+	err := WalkFilter(src, filter, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			if err := Mkdir(target, mode.Perm()|0700); err != nil && !os.IsExist(err) {
+				return err
+			}
+			dirs = append(dirs, dirMode{target, mode.Perm()})
+			return nil
+		case mode.IsRegular():
+			return copyFile(target, path, mode.Perm())
+		case mode&os.ModeSymlink != 0:
+			link, err := Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return Symlink(link, target)
+		default:
+			return &os.PathError{Op: "copytree", Path: path, Err: errUnsupportedFileType}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(dst, src string, perm os.FileMode) error {
+	in, err := Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// OpenFile does not change the mode of an existing file.
+	return Chmod(dst, perm)
+}
+
+// RemoveTree removes the file tree rooted at path, except the files for
+// which filter returns false. Directories that still contain excluded
+// files are kept, everything else is removed as by RemoveAll, which a nil
+// filter is equivalent to. If path does not exist, RemoveTree returns nil.
+func RemoveTree(path string, filter Filter) error {
+	if filter == nil {
+		return RemoveAll(path)
+	}
+	// Directories containing excluded files, which can not be removed.
+	kept := make(map[string]bool)
+	keep := FilterFunc(func(name string, info os.FileInfo) bool {
+		if filter.Include(name, info) {
+			return true
+		}
+		for dir := filepath.Dir(name); !kept[dir]; dir = filepath.Dir(dir) {
+			kept[dir] = true
+			if dir == path {
+				break
+			}
+		}
+		return false
+	})
+	var dirs []string
+	err := WalkFilter(path, keep, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			if name == path && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, name)
+			return nil
+		}
+		if err := Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Directories are walked before their contents, remove them in
+	// reverse order.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if kept[dirs[i]] {
+			continue
+		}
+		if err := Remove(dirs[i]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func makeTree(t *testing.T, root string, files ...string) {
+	for _, name := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func walkNames(t *testing.T, root string, filter Filter) []string {
+	var names []string
+	err := WalkFilter(root, filter, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestWalkFilter(t *testing.T) {
+	root := newDir("TestWalkFilter", t)
+	defer RemoveAll(root)
+	makeTree(t, root, "a/b/c.txt", "a/b/d.o", "a/e.o", "f.txt", "obj/x.txt", "empty/")
+
+	want := []string{".", "a", "a/b", "a/b/c.txt", "a/b/d.o", "a/e.o", "empty", "f.txt", "obj", "obj/x.txt"}
+	if got := walkNames(t, root, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk:\ngot:  %q\nwant: %q", got, want)
+	}
+
+	filter := FilterFunc(func(path string, info os.FileInfo) bool {
+		return filepath.Ext(path) != ".o" && !(info.IsDir() && info.Name() == "obj")
+	})
+	want = []string{".", "a", "a/b", "a/b/c.txt", "empty", "f.txt"}
+	if got := walkNames(t, root, filter); !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkFilter:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestWalkSkipDir(t *testing.T) {
+	root := newDir("TestWalkSkipDir", t)
+	defer RemoveAll(root)
+	makeTree(t, root, "a/x", "b/y", "c/z")
+
+	var names []string
+	err := Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "b" {
+			return filepath.SkipDir
+		}
+		names = append(names, filepath.Base(path))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Base(root), "a", "x", "c", "z"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Walk: got: %q want: %q", names, want)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src := newDir("TestCopyTree", t)
+	defer RemoveAll(src)
+	dst := newDir("TestCopyTree", t)
+	defer RemoveAll(dst)
+	makeTree(t, src, "a/b/c.txt", "a/d.o", "ro/x.txt")
+
+	if err := Chmod(filepath.Join(src, "a", "b", "c.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Chmod(filepath.Join(src, "ro"), 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer Chmod(filepath.Join(dst, "ro"), 0755)
+	defer Chmod(filepath.Join(src, "ro"), 0755)
+	if supportsSymlinks {
+		if err := Symlink("b/c.txt", filepath.Join(src, "a", "link")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter := FilterFunc(func(path string, _ os.FileInfo) bool {
+		return filepath.Ext(path) != ".o"
+	})
+	if err := CopyTree(dst, src, filter); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{".", "a", "a/b", "a/b/c.txt", "ro", "ro/x.txt"}
+	if supportsSymlinks {
+		want = []string{".", "a", "a/b", "a/b/c.txt", "a/link", "ro", "ro/x.txt"}
+	}
+	if got := walkNames(t, dst, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("CopyTree:\ngot:  %q\nwant: %q", got, want)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dst, "a", "b", "c.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a/b/c.txt" {
+		t.Errorf("CopyTree: copied data = %q; want: %q", data, "a/b/c.txt")
+	}
+	if runtime.GOOS != "windows" {
+		checkMode(t, filepath.Join(dst, "a", "b", "c.txt"), 0600)
+		checkMode(t, filepath.Join(dst, "ro"), 0555)
+	}
+	if supportsSymlinks {
+		link, err := Readlink(filepath.Join(dst, "a", "link"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link != "b/c.txt" {
+			t.Errorf("CopyTree: Readlink = %q; want: %q", link, "b/c.txt")
+		}
+	}
+}
+
+func TestRemoveTree(t *testing.T) {
+	root := newDir("TestRemoveTree", t)
+	defer RemoveAll(root)
+	makeTree(t, root, "a/b/c.txt", "a/b/d.o", "a/e.txt", "f.txt", "obj/x.txt", "empty/")
+
+	filter := FilterFunc(func(path string, info os.FileInfo) bool {
+		return filepath.Ext(path) != ".o" && !(info.IsDir() && info.Name() == "obj")
+	})
+	if err := RemoveTree(root, filter); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".", "a", "a/b", "a/b/d.o", "obj", "obj/x.txt"}
+	if got := walkNames(t, root, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("RemoveTree:\ngot:  %q\nwant: %q", got, want)
+	}
+
+	if err := RemoveTree(filepath.Join(root, "missing"), filter); err != nil {
+		t.Errorf("RemoveTree(missing): %v", err)
+	}
+	if err := RemoveTree(root, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lstat(root); !os.IsNotExist(err) {
+		t.Errorf("RemoveTree(nil filter): Lstat error = %v, want not exist", err)
+	}
+}
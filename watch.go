@@ -0,0 +1,84 @@
+package fs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrEventOverflow is reported on the Errors channel of a Watcher when the
+// system event queue overflowed and events were lost. Users that need an
+// accurate view of the tree should rescan it.
+var ErrEventOverflow = errors.New("fs: watcher event queue overflow")
+
+// Op describes a set of file operations.
+type Op uint32
+
+// These are the operations reported by a Watcher.
+const (
+	OpCreate Op = 1 << iota // file created, or moved into the watched tree
+	OpWrite                 // file contents modified
+	OpRemove                // file removed, or moved out of the watched tree
+	OpRename                // file renamed within the watched tree
+	OpChmod                 // file attributes changed
+)
+
+var opNames = []string{"CREATE", "WRITE", "REMOVE", "RENAME", "CHMOD"}
+
+func (op Op) String() string {
+	if op == 0 {
+		return "0"
+	}
+	var names []string
+	for i, name := range opNames {
+		if op&(1<<uint(i)) != 0 {
+			names = append(names, name)
+			op &^= 1 << uint(i)
+		}
+	}
+	if op != 0 {
+		names = append(names, "0x"+strconv.FormatUint(uint64(op), 16))
+	}
+	return strings.Join(names, "|")
+}
+
+// An Event describes a change to a file in a watched tree.
+type Event struct {
+	Name    string // path of the file
+	OldName string // previous path of a renamed file, only set for OpRename
+	Op      Op
+}
+
+func (e Event) String() string {
+	if e.OldName != "" {
+		return strconv.Quote(e.OldName) + " -> " + strconv.Quote(e.Name) + ": " + e.Op.String()
+	}
+	return strconv.Quote(e.Name) + ": " + e.Op.String()
+}
+
+// A Watcher reports changes to the files of a directory tree.
+//
+// Directories created in the tree, including those moved into it, are
+// watched as soon as they are observed and the files already present in
+// them are reported as created. As a result the creation of a file may be
+// reported more than once.
+type Watcher interface {
+	// Events returns the channel on which events are delivered. It is
+	// closed when the Watcher is closed.
+	Events() <-chan Event
+
+	// Errors returns the channel on which errors are delivered, such as
+	// ErrEventOverflow. It is closed when the Watcher is closed.
+	Errors() <-chan error
+
+	// Close stops watching the tree and closes the Events and Errors
+	// channels.
+	Close() error
+}
+
+// NewWatcher returns a Watcher for the directory tree rooted at root, using
+// the native notification mechanism of the operating system. On platforms
+// without one, a polling Watcher with the default options is returned.
+func NewWatcher(root string) (Watcher, error) {
+	return newWatcher(root)
+}
@@ -0,0 +1,323 @@
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_ATTRIB | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF | syscall.IN_DONT_FOLLOW | syscall.IN_EXCL_UNLINK
+
+// moveTimeout is how long an IN_MOVED_FROM event waits for the matching
+// IN_MOVED_TO event before it is reported as a removal.
+const moveTimeout = 10 * time.Millisecond
+
+var errWatcherClosed = errors.New("fs: watcher closed")
+
+type inotifyWatcher struct {
+	root   string
+	fd     int
+	file   *os.File // fd, registered with the runtime poller
+	events chan Event
+	errors chan error
+	done   chan struct{}
+	exited chan struct{}
+	once   sync.Once
+
+	// The below fields are only accessed by the readEvents goroutine,
+	// once it is started.
+	paths   map[int32]string // watched directories by descriptor
+	wds     map[string]int32
+	pending *inotifyMove
+}
+
+// inotifyMove is an IN_MOVED_FROM event awaiting its IN_MOVED_TO event.
+type inotifyMove struct {
+	name   string
+	cookie uint32
+	isDir  bool
+}
+
+func newWatcher(root string) (Watcher, error) {
+	root = filepath.Clean(root)
+	fi, err := Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, &os.PathError{Op: "watch", Path: root, Err: syscall.ENOTDIR}
+	}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &inotifyWatcher{
+		root:   root,
+		fd:     fd,
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan Event),
+		errors: make(chan error),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+		paths:  make(map[int32]string),
+		wds:    make(map[string]int32),
+	}
+	if err := w.addWatch(root); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	if err := w.addTree(root, false); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	go w.readEvents()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Events() <-chan Event { return w.events }
+func (w *inotifyWatcher) Errors() <-chan error { return w.errors }
+
+func (w *inotifyWatcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		// Closing the file unblocks the pending Read.
+		err = w.file.Close()
+		<-w.exited
+	})
+	return err
+}
+
+func (w *inotifyWatcher) sendEvent(e Event) bool {
+	select {
+	case w.events <- e:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *inotifyWatcher) sendError(err error) bool {
+	select {
+	case w.errors <- err:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// addWatch watches the directory name, files that can not be watched
+// because they no longer exist or are not directories are ignored.
+func (w *inotifyWatcher) addWatch(name string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, name, inotifyMask|syscall.IN_ONLYDIR)
+	if err != nil {
+		if err == syscall.ENOENT || err == syscall.ENOTDIR {
+			return nil
+		}
+		return &os.PathError{Op: "inotify_add_watch", Path: name, Err: err}
+	}
+	// A directory may be watched again after it was moved.
+	if old, ok := w.paths[int32(wd)]; ok {
+		delete(w.wds, old)
+	}
+	w.paths[int32(wd)] = name
+	w.wds[name] = int32(wd)
+	return nil
+}
+
+// addTree watches the directories below root. If created is true the files
+// found are reported as created, since they may have been created before
+// their directory was watched.
+func (w *inotifyWatcher) addTree(root string, created bool) error {
+	return Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// The file was removed before it could be watched.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if info.IsDir() {
+			if err := w.addWatch(path); err != nil {
+				return err
+			}
+		}
+		if created && !w.sendEvent(Event{Name: path, Op: OpCreate}) {
+			return errWatcherClosed
+		}
+		return nil
+	})
+}
+
+// removeTree stops watching root and the directories below it.
+func (w *inotifyWatcher) removeTree(root string) {
+	for name, wd := range w.wds {
+		if name == root || strings.HasPrefix(name, root+string(filepath.Separator)) {
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.wds, name)
+			delete(w.paths, wd)
+		}
+	}
+}
+
+// moveTree updates the paths of the watched directories below oldname
+// after it was renamed to newname.
+func (w *inotifyWatcher) moveTree(oldname, newname string) {
+	for name, wd := range w.wds {
+		if name == oldname || strings.HasPrefix(name, oldname+string(filepath.Separator)) {
+			name2 := newname + name[len(oldname):]
+			delete(w.wds, name)
+			w.wds[name2] = wd
+			w.paths[wd] = name2
+		}
+	}
+}
+
+// flushMove reports a pending IN_MOVED_FROM event without a matching
+// IN_MOVED_TO event, the file was moved out of the watched tree.
+func (w *inotifyWatcher) flushMove() bool {
+	m := w.pending
+	if m == nil {
+		return true
+	}
+	w.pending = nil
+	if m.isDir {
+		w.removeTree(m.name)
+	}
+	return w.sendEvent(Event{Name: m.name, Op: OpRemove})
+}
+
+func (w *inotifyWatcher) readEvents() {
+	defer func() {
+		close(w.events)
+		close(w.errors)
+		close(w.exited)
+	}()
+
+	var buf [syscall.SizeofInotifyEvent * 4096]byte
+	for {
+		if w.pending != nil {
+			w.file.SetReadDeadline(time.Now().Add(moveTimeout))
+		}
+		n, err := w.file.Read(buf[:])
+		if w.pending != nil {
+			w.file.SetReadDeadline(time.Time{})
+		}
+		if err != nil {
+			select {
+			case <-w.done:
+				return
+			default:
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				if !w.flushMove() {
+					return
+				}
+				continue
+			}
+			w.sendError(err)
+			return
+		}
+
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			off += syscall.SizeofInotifyEvent
+			var name string
+			if raw.Len > 0 {
+				b := buf[off : off+int(raw.Len)]
+				if i := bytes.IndexByte(b, 0); i >= 0 {
+					b = b[:i]
+				}
+				name = string(b)
+				off += int(raw.Len)
+			}
+			if !w.handleEvent(raw.Wd, raw.Mask, raw.Cookie, name) {
+				return
+			}
+		}
+	}
+}
+
+// handleEvent processes a single inotify event, it returns false if the
+// Watcher was closed.
+func (w *inotifyWatcher) handleEvent(wd int32, mask, cookie uint32, name string) bool {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		return w.flushMove() && w.sendError(ErrEventOverflow)
+	}
+	dir, ok := w.paths[wd]
+	if !ok {
+		return true
+	}
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+	isDir := mask&syscall.IN_ISDIR != 0
+
+	if w.pending != nil && (mask&syscall.IN_MOVED_TO == 0 || cookie != w.pending.cookie) {
+		if !w.flushMove() {
+			return false
+		}
+	}
+
+	switch {
+	case mask&syscall.IN_IGNORED != 0:
+		delete(w.paths, wd)
+		if w.wds[dir] == wd {
+			delete(w.wds, dir)
+		}
+	case mask&syscall.IN_MOVED_FROM != 0:
+		w.pending = &inotifyMove{name: path, cookie: cookie, isDir: isDir}
+	case mask&syscall.IN_MOVED_TO != 0:
+		if m := w.pending; m != nil {
+			w.pending = nil
+			if m.isDir {
+				w.moveTree(m.name, path)
+			}
+			return w.sendEvent(Event{Name: path, OldName: m.name, Op: OpRename})
+		}
+		return w.created(path, isDir)
+	case mask&syscall.IN_CREATE != 0:
+		return w.created(path, isDir)
+	case mask&syscall.IN_DELETE != 0:
+		return w.sendEvent(Event{Name: path, Op: OpRemove})
+	case mask&syscall.IN_DELETE_SELF != 0:
+		// The removal of other directories is reported by their parent.
+		if dir == w.root {
+			return w.sendEvent(Event{Name: path, Op: OpRemove})
+		}
+	case mask&syscall.IN_MODIFY != 0:
+		return w.sendEvent(Event{Name: path, Op: OpWrite})
+	case mask&syscall.IN_ATTRIB != 0:
+		return w.sendEvent(Event{Name: path, Op: OpChmod})
+	}
+	return true
+}
+
+// created reports the creation of path and, if it is a directory, starts
+// watching it.
+func (w *inotifyWatcher) created(path string, isDir bool) bool {
+	if !w.sendEvent(Event{Name: path, Op: OpCreate}) {
+		return false
+	}
+	if isDir {
+		if err := w.addWatch(path); err != nil {
+			return w.sendError(err)
+		}
+		if err := w.addTree(path, true); err != nil {
+			return err != errWatcherClosed && w.sendError(err)
+		}
+	}
+	return true
+}
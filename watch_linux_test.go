@@ -0,0 +1,239 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// waitEvent waits for an event matching want, ignoring any other events.
+func waitEvent(t *testing.T, w Watcher, want Event) {
+	t.Helper()
+	timer := time.NewTimer(watchTimeout)
+	defer timer.Stop()
+	var seen []Event
+	for {
+		select {
+		case e, ok := <-w.Events():
+			if !ok {
+				t.Fatalf("Events closed while waiting for: %s", want)
+			}
+			if e == want {
+				return
+			}
+			seen = append(seen, e)
+		case err := <-w.Errors():
+			t.Fatalf("error while waiting for %s: %v", want, err)
+		case <-timer.C:
+			t.Fatalf("timed out waiting for: %s\nseen: %v", want, seen)
+		}
+	}
+}
+
+func newTestWatcher(t *testing.T, root string) Watcher {
+	w, err := NewWatcher(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := w.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return w
+}
+
+func TestWatcherFiles(t *testing.T) {
+	root := newDir("TestWatcherFiles", t)
+	defer RemoveAll(root)
+	w := newTestWatcher(t, root)
+
+	name := filepath.Join(root, "a")
+	f, err := Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: name, Op: OpCreate})
+
+	if _, err := f.WriteString("hello"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	waitEvent(t, w, Event{Name: name, Op: OpWrite})
+
+	if err := Chmod(name, 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: name, Op: OpChmod})
+
+	newname := filepath.Join(root, "b")
+	if err := Rename(name, newname); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: newname, OldName: name, Op: OpRename})
+
+	if err := Remove(newname); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: newname, Op: OpRemove})
+}
+
+func TestWatcherSubdirectories(t *testing.T) {
+	root := newDir("TestWatcherSubdirectories", t)
+	defer RemoveAll(root)
+	if err := Mkdir(filepath.Join(root, "existing"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w := newTestWatcher(t, root)
+
+	// Directories present when the watcher was created.
+	name := filepath.Join(root, "existing", "a")
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: name, Op: OpCreate})
+
+	// Directories created with Mkdir.
+	dir := filepath.Join(root, "sub")
+	if err := Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: dir, Op: OpCreate})
+	name = filepath.Join(dir, "b")
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: name, Op: OpCreate})
+
+	// Nested directories created with MkdirAll, files created before
+	// the directories are watched must be reported.
+	dir = filepath.Join(root, "x", "y", "z")
+	if err := MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	name = filepath.Join(dir, "c")
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: name, Op: OpCreate})
+
+	// Directories created outside of the tree and moved into it.
+	outside := newDir("TestWatcherSubdirectories", t)
+	defer RemoveAll(outside)
+	if err := MkdirAll(filepath.Join(outside, "d", "e"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Rename(filepath.Join(outside, "d"), filepath.Join(root, "d")); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: filepath.Join(root, "d"), Op: OpCreate})
+	name = filepath.Join(root, "d", "e", "f")
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: name, Op: OpCreate})
+
+	// Renamed directories are still watched under their new name.
+	if err := Rename(filepath.Join(root, "d"), filepath.Join(root, "g")); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: filepath.Join(root, "g"), OldName: filepath.Join(root, "d"), Op: OpRename})
+	name = filepath.Join(root, "g", "e", "h")
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: name, Op: OpCreate})
+
+	// Directories moved out of the tree are reported as removed.
+	if err := Rename(filepath.Join(root, "g"), filepath.Join(outside, "g")); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, Event{Name: filepath.Join(root, "g"), Op: OpRemove})
+}
+
+func TestWatcherOverflow(t *testing.T) {
+	data, err := ioutil.ReadFile("/proc/sys/fs/inotify/max_queued_events")
+	if err != nil {
+		t.Skip(err)
+	}
+	max, err := strconv.Atoi(string(data[:len(data)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max > 1<<17 {
+		t.Skipf("max_queued_events is too large: %d", max)
+	}
+
+	root := newDir("TestWatcherOverflow", t)
+	defer RemoveAll(root)
+	w := newTestWatcher(t, root)
+
+	name := filepath.Join(root, "a")
+	f, err := Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Identical consecutive events are merged, so alternate between
+	// writes and attribute changes. Nothing is reading the events, so the
+	// queue will overflow.
+	for i := 0; i < max; i++ {
+		if _, err := f.Write([]byte{'a'}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Chmod(os.FileMode(0600 | i&1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timer := time.NewTimer(watchTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.Events():
+		case err := <-w.Errors():
+			if err != ErrEventOverflow {
+				t.Fatalf("Errors: got: %v want: %v", err, ErrEventOverflow)
+			}
+			return
+		case <-timer.C:
+			t.Fatal("timed out waiting for overflow")
+		}
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	root := newDir("TestWatcherClose", t)
+	defer RemoveAll(root)
+	w, err := NewWatcher(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "a"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Close must not block on undelivered events.
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range w.Events() {
+	}
+	if _, ok := <-w.Errors(); ok {
+		t.Error("Errors not closed")
+	}
+}
+
+func TestWatcherNotDir(t *testing.T) {
+	f := newFile("TestWatcherNotDir", t)
+	defer Remove(f.Name())
+	f.Close()
+	if _, err := NewWatcher(f.Name()); err == nil {
+		t.Error("NewWatcher: expected an error for a regular file")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+// Native notifications are not implemented on this platform, so a polling
+// watcher is used.
+func newWatcher(root string) (Watcher, error) {
+	return NewPollingWatcher(root, nil)
+}
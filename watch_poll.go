@@ -0,0 +1,356 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultPollInterval is the default interval between scans of a polling
+// Watcher.
+const DefaultPollInterval = time.Second
+
+// PollOptions configure a polling Watcher.
+type PollOptions struct {
+	// Interval is the time between scans of the tree. If zero,
+	// DefaultPollInterval is used.
+	Interval time.Duration
+
+	// Debounce is how long the changes to a file are held back, and
+	// merged, until the file stops changing. If zero, changes are
+	// reported by the scan that observed them.
+	Debounce time.Duration
+
+	// Clock is used to schedule scans. If nil, SystemClock is used.
+	Clock Clock
+}
+
+// NewPollingWatcher returns a Watcher for the directory tree rooted at root
+// that detects changes by periodically scanning the tree with Lstat. It
+// works on any file system, including network and FUSE file systems that
+// do not support native notifications, but may miss changes that are
+// reverted between two scans.
+//
+// Renames are detected by matching the device and inode numbers of
+// removed and created files. If the platform does not provide them,
+// renames are reported as a removal followed by a creation.
+func NewPollingWatcher(root string, opts *PollOptions) (Watcher, error) {
+	root = filepath.Clean(root)
+	fi, err := Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, &os.PathError{Op: "watch", Path: root, Err: syscall.ENOTDIR}
+	}
+	w := &pollWatcher{
+		root:    root,
+		events:  make(chan Event),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+		pending: make(map[string]*pollEvent),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Interval <= 0 {
+		w.opts.Interval = DefaultPollInterval
+	}
+	if w.opts.Clock == nil {
+		w.opts.Clock = SystemClock
+	}
+	w.files, err = w.scan()
+	if err != nil {
+		return nil, err
+	}
+	go w.poll()
+	return w, nil
+}
+
+type pollWatcher struct {
+	root   string
+	opts   PollOptions
+	events chan Event
+	errors chan error
+	done   chan struct{}
+	exited chan struct{}
+	once   sync.Once
+
+	// The below fields are only accessed by the poll goroutine, once it
+	// is started.
+	files   map[string]pollState
+	pending map[string]*pollEvent
+	seq     int
+}
+
+// pollState is the state of a file observed by a scan.
+type pollState struct {
+	id    FileID
+	hasID bool
+	size  int64
+	mtime time.Time
+	mode  os.FileMode
+}
+
+// replacedBy reports whether the file was replaced by a different file.
+func (s pollState) replacedBy(t pollState) bool {
+	if s.mode.Type() != t.mode.Type() {
+		return true
+	}
+	return s.hasID && t.hasID && s.id != t.id
+}
+
+// pollEvent is a pending event, held back until it is debounced.
+type pollEvent struct {
+	Event
+	seq  int       // order in which the event was first observed
+	last time.Time // time of the last change
+}
+
+func (w *pollWatcher) Events() <-chan Event { return w.events }
+func (w *pollWatcher) Errors() <-chan error { return w.errors }
+
+func (w *pollWatcher) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+		<-w.exited
+	})
+	return nil
+}
+
+func (w *pollWatcher) sendEvent(e Event) bool {
+	select {
+	case w.events <- e:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *pollWatcher) sendError(err error) bool {
+	select {
+	case w.errors <- err:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *pollWatcher) poll() {
+	defer func() {
+		close(w.events)
+		close(w.errors)
+		close(w.exited)
+	}()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.opts.Clock.After(w.opts.Interval):
+		}
+		files, err := w.scan()
+		if err != nil {
+			if !w.sendError(err) {
+				return
+			}
+			continue
+		}
+		now := w.opts.Clock.Now()
+		for _, e := range diffPollStates(w.files, files) {
+			w.merge(e, now)
+		}
+		w.files = files
+		if !w.flush(now) {
+			return
+		}
+	}
+}
+
+// scan returns the state of the files in the tree. A missing root is
+// treated as an empty tree.
+func (w *pollWatcher) scan() (map[string]pollState, error) {
+	files := make(map[string]pollState)
+	err := Walk(w.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Files removed during the scan are reported by the next one.
+			if os.IsNotExist(err) && path != w.root {
+				return nil
+			}
+			return err
+		}
+		if path == w.root {
+			return nil
+		}
+		s := pollState{
+			size:  info.Size(),
+			mtime: info.ModTime(),
+			mode:  info.Mode(),
+		}
+		s.id, s.hasID = FileIDOf(info)
+		if !s.hasID {
+			// The FileInfos of Windows do not include the ID, it is
+			// read from the file instead, at the cost of opening it.
+			if id, err := LgetFileID(path); err == nil {
+				s.id, s.hasID = id, true
+			}
+		}
+		files[path] = s
+		return nil
+	})
+	if err != nil && os.IsNotExist(err) {
+		err = nil
+	}
+	return files, err
+}
+
+// merge adds e to the pending events, merging it with any pending event
+// for the same file.
+func (w *pollWatcher) merge(e Event, now time.Time) {
+	p, ok := w.pending[e.Name]
+	if !ok {
+		w.seq++
+		w.pending[e.Name] = &pollEvent{Event: e, seq: w.seq, last: now}
+		return
+	}
+	p.last = now
+	if p.Op&OpCreate != 0 {
+		// The file was created and removed before it was reported,
+		// otherwise reporting its creation is sufficient.
+		if e.Op&OpRemove != 0 && e.Op&OpCreate == 0 {
+			delete(w.pending, e.Name)
+		}
+		return
+	}
+	p.Op |= e.Op
+	if e.OldName != "" {
+		p.OldName = e.OldName
+	}
+}
+
+// flush sends the pending events of files that did not change for the
+// debounce period, it returns false if the Watcher was closed.
+func (w *pollWatcher) flush(now time.Time) bool {
+	var ready []*pollEvent
+	for name, p := range w.pending {
+		if now.Sub(p.last) >= w.opts.Debounce {
+			ready = append(ready, p)
+			delete(w.pending, name)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		return ready[i].seq < ready[j].seq
+	})
+	for _, p := range ready {
+		if !w.sendEvent(p.Event) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffPollStates returns the events that transform the tree old into cur.
+// Renames are reported first, followed by removals, creations and
+// modifications, each sorted by name.
+func diffPollStates(old, cur map[string]pollState) []Event {
+	var removed, created []string
+	var modified []Event
+	for name, o := range old {
+		c, ok := cur[name]
+		switch {
+		case !ok:
+			removed = append(removed, name)
+		case o.replacedBy(c):
+			removed = append(removed, name)
+			created = append(created, name)
+		default:
+			var op Op
+			// The modification time of directories changes with their
+			// entries, which are reported separately.
+			if !c.mode.IsDir() && (o.size != c.size || !o.mtime.Equal(c.mtime)) {
+				op |= OpWrite
+			}
+			if o.mode != c.mode {
+				op |= OpChmod
+			}
+			if op != 0 {
+				modified = append(modified, Event{Name: name, Op: op})
+			}
+		}
+	}
+	for name := range cur {
+		if _, ok := old[name]; !ok {
+			created = append(created, name)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(created)
+	sort.Slice(modified, func(i, j int) bool {
+		return modified[i].Name < modified[j].Name
+	})
+
+	// Match removed and created files by inode.
+	createdByID := make(map[FileID]string)
+	for _, name := range created {
+		if c := cur[name]; c.hasID {
+			createdByID[c.id] = name
+		}
+	}
+	var renames []Event
+	renamedFrom := make(map[string]bool)
+	renamedTo := make(map[string]bool)
+	for _, name := range removed {
+		o := old[name]
+		if !o.hasID {
+			continue
+		}
+		if newname, ok := createdByID[o.id]; ok && newname != name {
+			renames = append(renames, Event{Name: newname, OldName: name, Op: OpRename})
+			renamedFrom[name] = true
+			renamedTo[newname] = true
+		}
+	}
+
+	events := make([]Event, 0, len(removed)+len(created)+len(modified))
+	var dirs []Event // renamed directories
+	for _, e := range renames {
+		// The files below a renamed directory are renamed with it.
+		implied := false
+		for _, d := range dirs {
+			if underDir(e.OldName, d.OldName) && underDir(e.Name, d.Name) &&
+				e.OldName[len(d.OldName):] == e.Name[len(d.Name):] {
+				implied = true
+				break
+			}
+		}
+		if implied {
+			continue
+		}
+		if cur[e.Name].mode.IsDir() {
+			dirs = append(dirs, e)
+		}
+		events = append(events, e)
+	}
+	for _, name := range removed {
+		// A file replaced by a renamed file is not reported as removed.
+		if !renamedFrom[name] && !renamedTo[name] {
+			events = append(events, Event{Name: name, Op: OpRemove})
+		}
+	}
+	for _, name := range created {
+		if !renamedTo[name] {
+			events = append(events, Event{Name: name, Op: OpCreate})
+		}
+	}
+	return append(events, modified...)
+}
+
+// underDir reports whether name is below the directory dir.
+func underDir(name, dir string) bool {
+	return len(name) > len(dir) && strings.HasPrefix(name, dir) &&
+		os.IsPathSeparator(name[len(dir)])
+}
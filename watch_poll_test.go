@@ -0,0 +1,188 @@
+package fs
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const testPollInterval = time.Second
+
+// watchTimeout is how long tests wait for the events of a Watcher.
+const watchTimeout = 5 * time.Second
+
+func newTestPollingWatcher(t *testing.T, root string, debounce time.Duration) (Watcher, *fakeClock) {
+	clock := newFakeClock()
+	w, err := NewPollingWatcher(root, &PollOptions{
+		Interval: testPollInterval,
+		Debounce: debounce,
+		Clock:    clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	<-clock.blocked
+	return w, clock
+}
+
+// pollEvents advances the clock by d and returns the events reported
+// before the watcher waits for the clock again.
+func pollEvents(t *testing.T, w Watcher, clock *fakeClock, d time.Duration) []Event {
+	t.Helper()
+	clock.Advance(d)
+	var events []Event
+	timer := time.NewTimer(watchTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case e := <-w.Events():
+			events = append(events, e)
+		case err := <-w.Errors():
+			t.Fatal(err)
+		case <-clock.blocked:
+			return events
+		case <-timer.C:
+			t.Fatalf("timed out waiting for poll, events: %v", events)
+		}
+	}
+}
+
+func expectEvents(t *testing.T, got []Event, want ...Event) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestPollingWatcher(t *testing.T) {
+	root := newDir("TestPollingWatcher", t)
+	defer RemoveAll(root)
+	if err := Mkdir(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w, clock := newTestPollingWatcher(t, root, 0)
+
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval))
+
+	name := filepath.Join(root, "dir", "a")
+	if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is scanned before the interval elapsed.
+	clock.Advance(testPollInterval / 2)
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval/2),
+		Event{Name: name, Op: OpCreate})
+
+	if err := ioutil.WriteFile(name, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval),
+		Event{Name: name, Op: OpWrite})
+
+	if runtime.GOOS != "windows" {
+		if err := Chmod(name, 0600); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t, pollEvents(t, w, clock, testPollInterval),
+			Event{Name: name, Op: OpChmod})
+	}
+
+	if err := Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval),
+		Event{Name: name, Op: OpRemove})
+}
+
+func TestPollingWatcherRename(t *testing.T) {
+	if runtime.GOOS == "plan9" {
+		t.Skipf("inode numbers are not available on %s", runtime.GOOS)
+	}
+	root := newDir("TestPollingWatcherRename", t)
+	defer RemoveAll(root)
+	makeTree(t, root, "a", "d/x", "d/y/z")
+	w, clock := newTestPollingWatcher(t, root, 0)
+
+	if err := Rename(filepath.Join(root, "a"), filepath.Join(root, "b")); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval),
+		Event{Name: filepath.Join(root, "b"), OldName: filepath.Join(root, "a"), Op: OpRename})
+
+	// Only the directory is reported as renamed, not its contents.
+	if err := Rename(filepath.Join(root, "d"), filepath.Join(root, "e")); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval),
+		Event{Name: filepath.Join(root, "e"), OldName: filepath.Join(root, "d"), Op: OpRename})
+
+	// A file replaced by another one, such as an editor saving a file.
+	tmp := filepath.Join(root, "e", "x.tmp")
+	if err := ioutil.WriteFile(tmp, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval),
+		Event{Name: tmp, Op: OpCreate})
+	if err := Rename(tmp, filepath.Join(root, "e", "x")); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval),
+		Event{Name: filepath.Join(root, "e", "x"), OldName: tmp, Op: OpRename})
+}
+
+func TestPollingWatcherDebounce(t *testing.T) {
+	root := newDir("TestPollingWatcherDebounce", t)
+	defer RemoveAll(root)
+	w, clock := newTestPollingWatcher(t, root, 2*testPollInterval)
+
+	name := filepath.Join(root, "a")
+	for i := 0; i < 3; i++ {
+		if err := ioutil.WriteFile(name, make([]byte, i), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// The file keeps changing, nothing is reported.
+		expectEvents(t, pollEvents(t, w, clock, testPollInterval))
+	}
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval))
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval),
+		Event{Name: name, Op: OpCreate})
+
+	// Files created and removed within the debounce period are not
+	// reported.
+	tmp := filepath.Join(root, "tmp")
+	if err := ioutil.WriteFile(tmp, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, pollEvents(t, w, clock, testPollInterval))
+	if err := Remove(tmp); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		expectEvents(t, pollEvents(t, w, clock, testPollInterval))
+	}
+}
+
+func TestPollingWatcherClose(t *testing.T) {
+	root := newDir("TestPollingWatcherClose", t)
+	defer RemoveAll(root)
+	w, clock := newTestPollingWatcher(t, root, 0)
+	if err := ioutil.WriteFile(filepath.Join(root, "a"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(testPollInterval)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range w.Events() {
+	}
+	if _, ok := <-w.Errors(); ok {
+		t.Error("Errors not closed")
+	}
+}
@@ -0,0 +1,299 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// A WorkDir is an FS that resolves relative paths against its own working
+// directory instead of the one of the process. Unlike with Chdir, several
+// goroutines may each use their own WorkDir concurrently. Absolute paths
+// are used as is.
+//
+// The directory is held open. On Linux relative paths are resolved against
+// the open directory, so they remain valid if the directory is renamed. On
+// other platforms they are resolved against the path of the directory.
+//
+// Errors refer to files by the names passed to the methods.
+type WorkDir struct {
+	mu  sync.RWMutex
+	dir *os.File // nil once closed
+}
+
+// OpenWorkDir returns a WorkDir whose working directory is dir, which is
+// resolved against the working directory of the process.
+// If there is an error, it will be of type *PathError.
+func OpenWorkDir(dir string) (*WorkDir, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, &os.PathError{Op: "openworkdir", Path: dir, Err: err}
+	}
+	f, err := openDir(abs, func() (*os.File, error) { return Open(abs) })
+	if err != nil {
+		return nil, relPathError(err, dir)
+	}
+	return &WorkDir{dir: f}, nil
+}
+
+// openDir returns the file opened by open if it is a directory.
+func openDir(name string, open func() (*os.File, error)) (*os.File, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err == nil && !fi.IsDir() {
+		err = &os.PathError{Op: "open", Path: name, Err: syscall.ENOTDIR}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Close closes the working directory, the WorkDir can not be used anymore.
+func (w *WorkDir) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.dir == nil {
+		return &os.PathError{Op: "close", Path: "", Err: os.ErrClosed}
+	}
+	err := w.dir.Close()
+	w.dir = nil
+	return err
+}
+
+// Chdir changes the working directory of w to dir, which is resolved
+// against the current one.
+// If there is an error, it will be of type *PathError.
+func (w *WorkDir) Chdir(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.dir == nil {
+		return &os.PathError{Op: "chdir", Path: dir, Err: os.ErrClosed}
+	}
+	var f *os.File
+	var err error
+	if isRelative(dir) {
+		f, err = openDir(dir, func() (*os.File, error) {
+			return workDirChdir(w.dir, dir)
+		})
+	} else {
+		f, err = openDir(dir, func() (*os.File, error) { return Open(filepath.Clean(dir)) })
+	}
+	if err != nil {
+		if pe, ok := err.(*os.PathError); ok {
+			pe.Op = "chdir"
+		}
+		return relPathError(err, dir)
+	}
+	w.dir.Close()
+	w.dir = f
+	return nil
+}
+
+// Getwd returns the absolute path of the working directory of w.
+func (w *WorkDir) Getwd() (string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.dir == nil {
+		return "", &os.PathError{Op: "getwd", Path: "", Err: os.ErrClosed}
+	}
+	return workDirPath(w.dir), nil
+}
+
+// isRelative reports whether name is resolved against the working
+// directory. On Windows names like \dir, which are relative to the current
+// drive, are not.
+func isRelative(name string) bool {
+	return name != "" && !filepath.IsAbs(name) && filepath.VolumeName(name) == "" &&
+		!os.IsPathSeparator(name[0])
+}
+
+// resolve returns the path of name for the functions of this package, the
+// read lock of w must be held.
+func (w *WorkDir) resolve(name string) string {
+	if !isRelative(name) {
+		return name
+	}
+	return joinPath(workDirBase(w.dir), name)
+}
+
+// do calls fn with the resolved path of name and replaces the path in the
+// error returned by fn with name.
+func (w *WorkDir) do(op, name string, fn func(path string) error) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.dir == nil {
+		return &os.PathError{Op: op, Path: name, Err: os.ErrClosed}
+	}
+	path := w.resolve(name)
+	return unresolveError(fn(path), path, name)
+}
+
+// do2 is like do for the operations on two files.
+func (w *WorkDir) do2(op, oldname, newname string, fn func(oldpath, newpath string) error) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.dir == nil {
+		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: os.ErrClosed}
+	}
+	oldpath, newpath := w.resolve(oldname), w.resolve(newname)
+	err := unresolveError(fn(oldpath, newpath), oldpath, oldname)
+	return unresolveError(err, newpath, newname)
+}
+
+// unresolveError replaces path, and the paths below it, with name in the
+// paths of err.
+func unresolveError(err error, path, name string) error {
+	if err == nil || path == name {
+		return err
+	}
+	replace := func(s *string) {
+		if *s == path {
+			*s = name
+		} else if strings.HasPrefix(*s, path) && os.IsPathSeparator((*s)[len(path)]) {
+			*s = name + (*s)[len(path):]
+		}
+	}
+	switch e := err.(type) {
+	case *os.PathError:
+		replace(&e.Path)
+	case *os.LinkError:
+		replace(&e.Old)
+		replace(&e.New)
+	}
+	return err
+}
+
+func (w *WorkDir) Chmod(name string, mode os.FileMode) error {
+	return w.do("chmod", name, func(path string) error { return Chmod(path, mode) })
+}
+
+func (w *WorkDir) Chown(name string, uid, gid int) error {
+	return w.do("chown", name, func(path string) error { return Chown(path, uid, gid) })
+}
+
+func (w *WorkDir) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return w.do("chtimes", name, func(path string) error { return Chtimes(path, atime, mtime) })
+}
+
+func (w *WorkDir) Lchmod(name string, mode os.FileMode) error {
+	return w.do("lchmod", name, func(path string) error { return Lchmod(path, mode) })
+}
+
+func (w *WorkDir) Lchown(name string, uid, gid int) error {
+	return w.do("lchown", name, func(path string) error { return Lchown(path, uid, gid) })
+}
+
+func (w *WorkDir) Link(oldname, newname string) error {
+	return w.do2("link", oldname, newname, Link)
+}
+
+func (w *WorkDir) Mkdir(name string, perm os.FileMode) error {
+	return w.do("mkdir", name, func(path string) error { return Mkdir(path, perm) })
+}
+
+func (w *WorkDir) MkdirAll(path string, perm os.FileMode) error {
+	return w.do("mkdir", path, func(path string) error { return MkdirAll(path, perm) })
+}
+
+func (w *WorkDir) ReadDir(name string) ([]os.DirEntry, error) {
+	var entries []os.DirEntry
+	err := w.do("readdir", name, func(path string) (err error) {
+		entries, err = ReadDir(path)
+		return err
+	})
+	return entries, err
+}
+
+func (w *WorkDir) Readlink(name string) (string, error) {
+	var target string
+	err := w.do("readlink", name, func(path string) (err error) {
+		target, err = Readlink(path)
+		return err
+	})
+	return target, err
+}
+
+func (w *WorkDir) Remove(name string) error {
+	return w.do("remove", name, Remove)
+}
+
+func (w *WorkDir) RemoveAll(path string) error {
+	return w.do("removeall", path, RemoveAll)
+}
+
+func (w *WorkDir) Rename(oldpath, newpath string) error {
+	return w.do2("rename", oldpath, newpath, Rename)
+}
+
+// Symlink creates newname as a symbolic link to oldname. Like the target of
+// any symbolic link, a relative oldname is resolved against the directory
+// of the link when the link is followed.
+func (w *WorkDir) Symlink(oldname, newname string) error {
+	return w.do("symlink", newname, func(path string) error { return Symlink(oldname, path) })
+}
+
+func (w *WorkDir) Truncate(name string, size int64) error {
+	return w.do("truncate", name, func(path string) error { return Truncate(path, size) })
+}
+
+func (w *WorkDir) Create(name string) (File, error) {
+	return w.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (w *WorkDir) Open(name string) (File, error) {
+	return w.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile opens the named file like the function OpenFile. The name of the
+// returned file is name.
+func (w *WorkDir) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	var f *os.File
+	err := w.do("open", name, func(path string) (err error) {
+		if path == name {
+			f, err = OpenFile(name, flag, perm)
+		} else {
+			f, err = workDirOpen(w.dir, name, flag, perm)
+		}
+		return err
+	})
+	return toFile(f, err)
+}
+
+func (w *WorkDir) ReadFile(name string) ([]byte, error) {
+	var data []byte
+	err := w.do("readfile", name, func(path string) (err error) {
+		data, err = ReadFile(path)
+		return err
+	})
+	return data, err
+}
+
+func (w *WorkDir) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return w.do("writefile", name, func(path string) error { return WriteFile(path, data, perm) })
+}
+
+func (w *WorkDir) Lstat(name string) (os.FileInfo, error) {
+	var fi os.FileInfo
+	err := w.do("lstat", name, func(path string) (err error) {
+		fi, err = Lstat(path)
+		return err
+	})
+	return fi, err
+}
+
+func (w *WorkDir) Stat(name string) (os.FileInfo, error) {
+	var fi os.FileInfo
+	err := w.do("stat", name, func(path string) (err error) {
+		fi, err = Stat(path)
+		return err
+	})
+	return fi, err
+}
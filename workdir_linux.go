@@ -0,0 +1,61 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+var hasProcFd struct {
+	once sync.Once
+	ok   bool
+}
+
+// workDirBase returns the path relative paths of the working directory dir
+// are appended to.
+func workDirBase(dir *os.File) string {
+	hasProcFd.once.Do(func() {
+		_, err := os.Stat("/proc/self/fd")
+		hasProcFd.ok = err == nil
+	})
+	if hasProcFd.ok {
+		return procFdPath(dir)
+	}
+	return dir.Name()
+}
+
+// workDirPath returns the current path of the working directory dir.
+func workDirPath(dir *os.File) string {
+	if base := workDirBase(dir); base != dir.Name() {
+		if path, err := os.Readlink(base); err == nil && len(path) > 0 && path[0] == '/' {
+			return path
+		}
+	}
+	return dir.Name()
+}
+
+// workDirOpen opens the relative name in the working directory dir.
+func workDirOpen(dir *os.File, name string, flag int, perm os.FileMode) (*os.File, error) {
+	return openat(dir, name, name, flag, perm)
+}
+
+// workDirChdir opens the relative name of the new working directory in
+// the working directory dir, the name of the returned file is its absolute
+// path.
+func workDirChdir(dir *os.File, name string) (*os.File, error) {
+	return openat(dir, name, filepath.Join(workDirPath(dir), name), os.O_RDONLY|syscall.O_DIRECTORY, 0)
+}
+
+// openat opens name relative to dir, the returned file is named fileName.
+func openat(dir *os.File, name, fileName string, flag int, perm os.FileMode) (*os.File, error) {
+	var fd int
+	err := ignoringEINTR(func() (err error) {
+		fd, err = syscall.Openat(int(dir.Fd()), name, flag|syscall.O_CLOEXEC, syscallMode(perm))
+		return err
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return os.NewFile(uintptr(fd), fileName), nil
+}
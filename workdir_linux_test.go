@@ -0,0 +1,31 @@
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkDirRenamed(t *testing.T) {
+	root := TempTree(t, map[string]string{"old/a.txt": "a"})
+	w, err := OpenWorkDir(filepath.Join(root, "old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if err := Rename(filepath.Join(root, "old"), filepath.Join(root, "new")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := w.ReadFile("a.txt"); err != nil || string(got) != "a" {
+		t.Errorf("ReadFile(a.txt) = %q, %v", got, err)
+	}
+	if dir, err := w.Getwd(); err != nil || dir != filepath.Join(root, "new") {
+		t.Errorf("Getwd = %q, %v, want %q", dir, err, filepath.Join(root, "new"))
+	}
+	if err := w.MkdirAll("x/y", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Stat(filepath.Join(root, "new", "x", "y")); err != nil {
+		t.Error(err)
+	}
+}
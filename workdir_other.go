@@ -0,0 +1,32 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// workDirBase returns the path relative paths of the working directory dir
+// are appended to.
+func workDirBase(dir *os.File) string {
+	return dir.Name()
+}
+
+// workDirPath returns the current path of the working directory dir.
+func workDirPath(dir *os.File) string {
+	return dir.Name()
+}
+
+// workDirOpen opens the relative name in the working directory dir.
+func workDirOpen(dir *os.File, name string, flag int, perm os.FileMode) (*os.File, error) {
+	return OpenFile(joinPath(dir.Name(), name), flag, perm)
+}
+
+// workDirChdir opens the relative name of the new working directory in
+// the working directory dir, the name of the returned file is its absolute
+// path.
+func workDirChdir(dir *os.File, name string) (*os.File, error) {
+	return Open(filepath.Join(dir.Name(), name))
+}
@@ -0,0 +1,147 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+var (
+	_ FS = SystemFS
+	_ FS = (*WorkDir)(nil)
+)
+
+func TestWorkDir(t *testing.T) {
+	root := TempTree(t, map[string]string{
+		"a.txt":   "a",
+		"b/c.txt": "c",
+	})
+	w, err := OpenWorkDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if got, err := w.ReadFile("a.txt"); err != nil || string(got) != "a" {
+		t.Errorf("ReadFile(a.txt) = %q, %v", got, err)
+	}
+	if err := w.WriteFile("b/d.txt", []byte("d"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, filepath.Join(root, "b", "d.txt")); got != "d" {
+		t.Errorf("b/d.txt = %q, want %q", got, "d")
+	}
+	if err := w.Rename("a.txt", "b/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Stat(filepath.Join(root, "b", "a.txt")); err != nil {
+		t.Errorf("Rename: %v", err)
+	}
+
+	f, err := w.Create("new.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name() != "new.txt" {
+		t.Errorf("Name = %q, want %q", f.Name(), "new.txt")
+	}
+	f.Write([]byte("new"))
+	f.Close()
+	if got := readTestFile(t, filepath.Join(root, "new.txt")); got != "new" {
+		t.Errorf("new.txt = %q, want %q", got, "new")
+	}
+
+	// Absolute paths are not resolved.
+	abs := filepath.Join(root, "b", "c.txt")
+	if fi, err := w.Stat(abs); err != nil || fi.Size() != 1 {
+		t.Errorf("Stat(%s) = %v, %v", abs, fi, err)
+	}
+
+	// Errors refer to the names passed.
+	_, err = w.Open("missing")
+	var pe *os.PathError
+	if !errors.As(err, &pe) || pe.Path != "missing" || !os.IsNotExist(err) {
+		t.Errorf("Open(missing): error = %v", err)
+	}
+	err = w.Rename("missing", "other")
+	var le *os.LinkError
+	if !errors.As(err, &le) || le.Old != "missing" || le.New != "other" {
+		t.Errorf("Rename(missing, other): error = %v", err)
+	}
+
+	if err := w.Chdir("b"); err != nil {
+		t.Fatal(err)
+	}
+	if dir, err := w.Getwd(); err != nil || dir != filepath.Join(root, "b") {
+		t.Errorf("Getwd = %q, %v, want %q", dir, err, filepath.Join(root, "b"))
+	}
+	if got, err := w.ReadFile("c.txt"); err != nil || string(got) != "c" {
+		t.Errorf("ReadFile(c.txt) after Chdir = %q, %v", got, err)
+	}
+	if err := w.Chdir("c.txt"); err == nil {
+		t.Error("Chdir to a file: expected error")
+	}
+	if err := w.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	if dir, err := w.Getwd(); err != nil || dir != root {
+		t.Errorf("Getwd = %q, %v, want %q", dir, err, root)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Stat("b"); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Stat after Close: error = %v, want %v", err, os.ErrClosed)
+	}
+}
+
+// Test that, unlike Chdir, the working directory of a WorkDir is not
+// program-wide.
+func TestWorkDirConcurrent(t *testing.T) {
+	root := TempTree(t, nil)
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const N = 10
+	var wg sync.WaitGroup
+	for i := 0; i < N; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dir := filepath.Join(root, fmt.Sprint(i))
+			if err := Mkdir(dir, 0755); err != nil {
+				t.Error(err)
+				return
+			}
+			w, err := OpenWorkDir(dir)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer w.Close()
+			for j := 0; j < 10; j++ {
+				if err := w.WriteFile("file", []byte(fmt.Sprint(i)), 0644); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < N; i++ {
+		name := filepath.Join(root, fmt.Sprint(i), "file")
+		if got := readTestFile(t, name); got != fmt.Sprint(i) {
+			t.Errorf("%s = %q, want %q", name, got, fmt.Sprint(i))
+		}
+	}
+	if wd, err := os.Getwd(); err != nil || wd != oldwd {
+		t.Errorf("working directory of the process changed to %q: %v", wd, err)
+	}
+}
@@ -0,0 +1,92 @@
+package fs
+
+import "os"
+
+// Flags for Setxattr and its variants.
+const (
+	XattrCreate  = 0x1 // fail if the attribute already exists
+	XattrReplace = 0x2 // fail if the attribute does not exist
+)
+
+// Extended attributes are only supported on Linux, on other platforms the
+// functions below return an error wrapping ErrNotSupported. Attribute names
+// include their namespace, for example "user.checksum". If the file system
+// does not support extended attributes the error wraps syscall.ENOTSUP and
+// if the attribute does not exist it wraps syscall.ENODATA.
+
+// Getxattr returns the value of the extended attribute attr of the named
+// file. If the file is a symbolic link, it returns the attribute of the
+// link's target.
+// If there is an error, it will be of type *PathError.
+func Getxattr(name, attr string) ([]byte, error) {
+	return getxattr(name, attr, true)
+}
+
+// Lgetxattr is like Getxattr, but if the file is a symbolic link it returns
+// the attribute of the link itself.
+func Lgetxattr(name, attr string) ([]byte, error) {
+	return getxattr(name, attr, false)
+}
+
+// Fgetxattr returns the value of the extended attribute attr of f.
+func Fgetxattr(f *os.File, attr string) ([]byte, error) {
+	return fgetxattr(f, attr)
+}
+
+// Setxattr sets the value of the extended attribute attr of the named file.
+// Flags may be XattrCreate or XattrReplace, if zero the attribute is
+// created or replaced as needed. If the file is a symbolic link, it sets
+// the attribute of the link's target.
+// If there is an error, it will be of type *PathError.
+func Setxattr(name, attr string, data []byte, flags int) error {
+	return setxattr(name, attr, data, flags, true)
+}
+
+// Lsetxattr is like Setxattr, but if the file is a symbolic link it sets
+// the attribute of the link itself.
+func Lsetxattr(name, attr string, data []byte, flags int) error {
+	return setxattr(name, attr, data, flags, false)
+}
+
+// Fsetxattr sets the value of the extended attribute attr of f.
+func Fsetxattr(f *os.File, attr string, data []byte, flags int) error {
+	return fsetxattr(f, attr, data, flags)
+}
+
+// Listxattr returns the names of the extended attributes of the named
+// file. If the file is a symbolic link, it lists the attributes of the
+// link's target.
+// If there is an error, it will be of type *PathError.
+func Listxattr(name string) ([]string, error) {
+	return listxattr(name, true)
+}
+
+// Llistxattr is like Listxattr, but if the file is a symbolic link it
+// lists the attributes of the link itself.
+func Llistxattr(name string) ([]string, error) {
+	return listxattr(name, false)
+}
+
+// Flistxattr returns the names of the extended attributes of f.
+func Flistxattr(f *os.File) ([]string, error) {
+	return flistxattr(f)
+}
+
+// Removexattr removes the extended attribute attr of the named file. If
+// the file is a symbolic link, it removes the attribute of the link's
+// target.
+// If there is an error, it will be of type *PathError.
+func Removexattr(name, attr string) error {
+	return removexattr(name, attr, true)
+}
+
+// Lremovexattr is like Removexattr, but if the file is a symbolic link it
+// removes the attribute of the link itself.
+func Lremovexattr(name, attr string) error {
+	return removexattr(name, attr, false)
+}
+
+// Fremovexattr removes the extended attribute attr of f.
+func Fremovexattr(f *os.File, attr string) error {
+	return fremovexattr(f, attr)
+}
@@ -0,0 +1,220 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+func xattrOp(op string, follow bool) string {
+	if follow {
+		return op
+	}
+	return "l" + op
+}
+
+func bufPtr(b []byte) unsafe.Pointer {
+	if len(b) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&b[0])
+}
+
+// isNoXattr reports whether err is returned for a missing attribute.
+func isNoXattr(err error) bool {
+	return errors.Is(err, syscall.ENODATA)
+}
+
+func errnoErr(e syscall.Errno) error {
+	if e != 0 {
+		return e
+	}
+	return nil
+}
+
+// sized calls fn, which behaves like getxattr(2) or listxattr(2), with a
+// buffer large enough for the value.
+func sized(fn func(dest []byte) (int, error)) ([]byte, error) {
+	for {
+		n, err := fn(nil)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return []byte{}, nil
+		}
+		buf := make([]byte, n)
+		n, err = fn(buf)
+		if err == syscall.ERANGE {
+			continue // the value grew
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+func splitXattrNames(b []byte) []string {
+	names := strings.Split(strings.TrimSuffix(string(b), "\x00"), "\x00")
+	if len(names) == 1 && names[0] == "" {
+		return []string{}
+	}
+	return names
+}
+
+func getxattr(name, attr string, follow bool) ([]byte, error) {
+	trap := uintptr(syscall.SYS_GETXATTR)
+	if !follow {
+		trap = syscall.SYS_LGETXATTR
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, &os.PathError{Op: xattrOp("getxattr", follow), Path: name, Err: err}
+	}
+	a, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return nil, &os.PathError{Op: xattrOp("getxattr", follow), Path: name, Err: err}
+	}
+	data, err := sized(func(dest []byte) (int, error) {
+		r, _, e := syscall.Syscall6(trap, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(a)),
+			uintptr(bufPtr(dest)), uintptr(len(dest)), 0, 0)
+		return int(r), errnoErr(e)
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: xattrOp("getxattr", follow), Path: name, Err: err}
+	}
+	return data, nil
+}
+
+func fgetxattr(f *os.File, attr string) ([]byte, error) {
+	a, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return nil, &os.PathError{Op: "fgetxattr", Path: f.Name(), Err: err}
+	}
+	var data []byte
+	err = controlFd(f, func(fd uintptr) error {
+		var err error
+		data, err = sized(func(dest []byte) (int, error) {
+			r, _, e := syscall.Syscall6(syscall.SYS_FGETXATTR, fd, uintptr(unsafe.Pointer(a)),
+				uintptr(bufPtr(dest)), uintptr(len(dest)), 0, 0)
+			return int(r), errnoErr(e)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "fgetxattr", Path: f.Name(), Err: err}
+	}
+	return data, nil
+}
+
+func setxattr(name, attr string, data []byte, flags int, follow bool) error {
+	trap := uintptr(syscall.SYS_SETXATTR)
+	if !follow {
+		trap = syscall.SYS_LSETXATTR
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return &os.PathError{Op: xattrOp("setxattr", follow), Path: name, Err: err}
+	}
+	a, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return &os.PathError{Op: xattrOp("setxattr", follow), Path: name, Err: err}
+	}
+	_, _, e := syscall.Syscall6(trap, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(a)),
+		uintptr(bufPtr(data)), uintptr(len(data)), uintptr(flags), 0)
+	if e != 0 {
+		return &os.PathError{Op: xattrOp("setxattr", follow), Path: name, Err: e}
+	}
+	return nil
+}
+
+func fsetxattr(f *os.File, attr string, data []byte, flags int) error {
+	a, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return &os.PathError{Op: "fsetxattr", Path: f.Name(), Err: err}
+	}
+	err = controlFd(f, func(fd uintptr) error {
+		_, _, e := syscall.Syscall6(syscall.SYS_FSETXATTR, fd, uintptr(unsafe.Pointer(a)),
+			uintptr(bufPtr(data)), uintptr(len(data)), uintptr(flags), 0)
+		return errnoErr(e)
+	})
+	if err != nil {
+		return &os.PathError{Op: "fsetxattr", Path: f.Name(), Err: err}
+	}
+	return nil
+}
+
+func listxattr(name string, follow bool) ([]string, error) {
+	trap := uintptr(syscall.SYS_LISTXATTR)
+	if !follow {
+		trap = syscall.SYS_LLISTXATTR
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, &os.PathError{Op: xattrOp("listxattr", follow), Path: name, Err: err}
+	}
+	data, err := sized(func(dest []byte) (int, error) {
+		r, _, e := syscall.Syscall(trap, uintptr(unsafe.Pointer(p)),
+			uintptr(bufPtr(dest)), uintptr(len(dest)))
+		return int(r), errnoErr(e)
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: xattrOp("listxattr", follow), Path: name, Err: err}
+	}
+	return splitXattrNames(data), nil
+}
+
+func flistxattr(f *os.File) ([]string, error) {
+	var data []byte
+	err := controlFd(f, func(fd uintptr) error {
+		var err error
+		data, err = sized(func(dest []byte) (int, error) {
+			r, _, e := syscall.Syscall(syscall.SYS_FLISTXATTR, fd,
+				uintptr(bufPtr(dest)), uintptr(len(dest)))
+			return int(r), errnoErr(e)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "flistxattr", Path: f.Name(), Err: err}
+	}
+	return splitXattrNames(data), nil
+}
+
+func removexattr(name, attr string, follow bool) error {
+	trap := uintptr(syscall.SYS_REMOVEXATTR)
+	if !follow {
+		trap = syscall.SYS_LREMOVEXATTR
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return &os.PathError{Op: xattrOp("removexattr", follow), Path: name, Err: err}
+	}
+	a, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return &os.PathError{Op: xattrOp("removexattr", follow), Path: name, Err: err}
+	}
+	_, _, e := syscall.Syscall(trap, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(a)), 0)
+	if e != 0 {
+		return &os.PathError{Op: xattrOp("removexattr", follow), Path: name, Err: e}
+	}
+	return nil
+}
+
+func fremovexattr(f *os.File, attr string) error {
+	a, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return &os.PathError{Op: "fremovexattr", Path: f.Name(), Err: err}
+	}
+	err = controlFd(f, func(fd uintptr) error {
+		_, _, e := syscall.Syscall(syscall.SYS_FREMOVEXATTR, fd, uintptr(unsafe.Pointer(a)), 0)
+		return errnoErr(e)
+	})
+	if err != nil {
+		return &os.PathError{Op: "fremovexattr", Path: f.Name(), Err: err}
+	}
+	return nil
+}
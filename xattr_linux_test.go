@@ -0,0 +1,132 @@
+package fs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"syscall"
+	"testing"
+)
+
+const testXattr = "user.fs.test"
+
+// skipIfNoXattr skips the test if err reports that the file system does not
+// support user extended attributes.
+func skipIfNoXattr(t *testing.T, err error) {
+	if errors.Is(err, syscall.ENOTSUP) {
+		t.Skipf("extended attributes not supported: %v", err)
+	}
+}
+
+func TestXattr(t *testing.T) {
+	f := newFile("TestXattr", t)
+	name := f.Name()
+	defer Remove(name)
+	defer f.Close()
+
+	err := Setxattr(name, testXattr, []byte("value"), 0)
+	skipIfNoXattr(t, err)
+	if err != nil {
+		t.Fatalf("Setxattr: %v", err)
+	}
+	data, err := Getxattr(name, testXattr)
+	if err != nil || string(data) != "value" {
+		t.Fatalf("Getxattr = %q, %v; want %q", data, err, "value")
+	}
+	if err := Setxattr(name, testXattr, nil, XattrCreate); !errors.Is(err, syscall.EEXIST) {
+		t.Errorf("Setxattr with XattrCreate: got %v; want EEXIST", err)
+	}
+	if err := Setxattr(name, testXattr+".missing", nil, XattrReplace); !errors.Is(err, syscall.ENODATA) {
+		t.Errorf("Setxattr with XattrReplace: got %v; want ENODATA", err)
+	}
+	if err := Setxattr(name, testXattr+".empty", nil, XattrCreate); err != nil {
+		t.Fatalf("Setxattr: %v", err)
+	}
+	if data, err := Getxattr(name, testXattr+".empty"); err != nil || len(data) != 0 {
+		t.Errorf("Getxattr = %q, %v; want empty value", data, err)
+	}
+
+	names, err := Listxattr(name)
+	if err != nil {
+		t.Fatalf("Listxattr: %v", err)
+	}
+	sort.Strings(names)
+	if want := []string{testXattr, testXattr + ".empty"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Listxattr = %q; want %q", names, want)
+	}
+
+	if err := Removexattr(name, testXattr); err != nil {
+		t.Fatalf("Removexattr: %v", err)
+	}
+	_, err = Getxattr(name, testXattr)
+	if !errors.Is(err, syscall.ENODATA) {
+		t.Errorf("Getxattr after Removexattr: got %v; want ENODATA", err)
+	}
+	if perr, ok := err.(*os.PathError); !ok || perr.Path != name {
+		t.Errorf("Getxattr error %#v is not a *PathError for %s", err, name)
+	}
+}
+
+func TestXattrFile(t *testing.T) {
+	f := newFile("TestXattrFile", t)
+	defer Remove(f.Name())
+	defer f.Close()
+
+	err := Fsetxattr(f, testXattr, []byte("file"), 0)
+	skipIfNoXattr(t, err)
+	if err != nil {
+		t.Fatalf("Fsetxattr: %v", err)
+	}
+	if data, err := Fgetxattr(f, testXattr); err != nil || string(data) != "file" {
+		t.Fatalf("Fgetxattr = %q, %v; want %q", data, err, "file")
+	}
+	if data, err := Getxattr(f.Name(), testXattr); err != nil || string(data) != "file" {
+		t.Fatalf("Getxattr = %q, %v; want %q", data, err, "file")
+	}
+	if names, err := Flistxattr(f); err != nil || !reflect.DeepEqual(names, []string{testXattr}) {
+		t.Errorf("Flistxattr = %q, %v; want %q", names, err, []string{testXattr})
+	}
+	if err := Fremovexattr(f, testXattr); err != nil {
+		t.Fatalf("Fremovexattr: %v", err)
+	}
+	if names, err := Flistxattr(f); err != nil || len(names) != 0 {
+		t.Errorf("Flistxattr = %q, %v; want no attributes", names, err)
+	}
+}
+
+func TestXattrSymlink(t *testing.T) {
+	if !supportsSymlinks {
+		t.Skip("symlinks not supported")
+	}
+	dir := newDir("TestXattrSymlink", t)
+	defer RemoveAll(dir)
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+	if err := ioutil.WriteFile(target, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Setxattr(link, testXattr, []byte("target"), 0)
+	skipIfNoXattr(t, err)
+	if err != nil {
+		t.Fatalf("Setxattr: %v", err)
+	}
+	if data, err := Getxattr(target, testXattr); err != nil || string(data) != "target" {
+		t.Fatalf("Getxattr = %q, %v; want %q", data, err, "target")
+	}
+	if _, err := Lgetxattr(link, testXattr); !errors.Is(err, syscall.ENODATA) {
+		t.Errorf("Lgetxattr of link: got %v; want ENODATA", err)
+	}
+	if names, err := Llistxattr(link); err != nil || len(names) != 0 {
+		t.Errorf("Llistxattr of link = %q, %v; want no attributes", names, err)
+	}
+	if err := Lremovexattr(target, testXattr); err != nil {
+		t.Errorf("Lremovexattr of target: %v", err)
+	}
+}
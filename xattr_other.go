@@ -0,0 +1,42 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+import "os"
+
+func getxattr(name, attr string, follow bool) ([]byte, error) {
+	return nil, &os.PathError{Op: "getxattr", Path: name, Err: ErrNotSupported}
+}
+
+func fgetxattr(f *os.File, attr string) ([]byte, error) {
+	return nil, &os.PathError{Op: "fgetxattr", Path: f.Name(), Err: ErrNotSupported}
+}
+
+func setxattr(name, attr string, data []byte, flags int, follow bool) error {
+	return &os.PathError{Op: "setxattr", Path: name, Err: ErrNotSupported}
+}
+
+func fsetxattr(f *os.File, attr string, data []byte, flags int) error {
+	return &os.PathError{Op: "fsetxattr", Path: f.Name(), Err: ErrNotSupported}
+}
+
+func listxattr(name string, follow bool) ([]string, error) {
+	return nil, &os.PathError{Op: "listxattr", Path: name, Err: ErrNotSupported}
+}
+
+func flistxattr(f *os.File) ([]string, error) {
+	return nil, &os.PathError{Op: "flistxattr", Path: f.Name(), Err: ErrNotSupported}
+}
+
+func removexattr(name, attr string, follow bool) error {
+	return &os.PathError{Op: "removexattr", Path: name, Err: ErrNotSupported}
+}
+
+func fremovexattr(f *os.File, attr string) error {
+	return &os.PathError{Op: "fremovexattr", Path: f.Name(), Err: ErrNotSupported}
+}
+
+func isNoXattr(err error) bool {
+	return false
+}